@@ -3,6 +3,7 @@ import { Game } from "../engine/game";
 import { Graphics } from "../engine/graphics";
 import { useAsyncError } from "../errorHandler";
 import { ErrorTypes } from "../errorTypes";
+import { Constants } from "../engine/constants";
 
 const GameField = ({ network, keyboard }) => {
     const canvasRef = useRef(null);
@@ -19,16 +20,34 @@ const GameField = ({ network, keyboard }) => {
         game.run();
 
         network.setOnWorld((message) => {
-            game.receiveWorld(message.opponentPosition, message.puckPosition, message.countA, message.countB);
+            game.receiveWorld(message.self, message.mallets, message.pucks, message.countA, message.countB);
+            game.receivePossession(message.possessionSide, message.possessionLeft);
+            game.receiveObstacles(message.obstacles);
+            game.receivePowerUps(message.modifiers, message.powerUps, message.effects);
+            game.receiveMatch(message.match);
+            game.receivePhase(message.phase, message.phaseLeft);
+        });
+        network.setOnEvent((message) => {
+            game.receiveEvent(message.eventType, message.side, message.powerUp);
+        });
+        network.setOnSpectators((message) => {
+            game.receiveSpectators(message.count);
+        });
+        network.setOnResult((message) => {
+            game.receiveResult(message.result);
         });
         network.setOnExitGame((message) => {
-            throwError(new Error(message.reason, {cause: ErrorTypes.gameExit}))
+            throwError(new Error(game.exitText(message.reason), {cause: ErrorTypes.gameExit}))
         })
 
         keyboard.onDebugHandler(() => {
             game.toggleDebug();
         })
 
+        keyboard.onPauseHandler(() => {
+            game.togglePause();
+        })
+
         return () => game.exit('Player leaved the room');
     }, []);
 
@@ -38,7 +57,7 @@ const GameField = ({ network, keyboard }) => {
 
     return (
         <div className="app__game-page">
-            <button className="app__button" onClick={() => leaveGame()}>Leave game</button>
+            <button className="app__button" onClick={() => leaveGame()}>{Constants.spectator ? 'Stop watching' : 'Leave game'}</button>
             <canvas className="app__game" ref={canvasRef}></canvas>
         </div>
     )
@@ -6,6 +6,7 @@ import { Network } from "../engine/network";
 import { useAsyncError } from "../errorHandler";
 import { ClientMessages } from "../protocol/messages";
 import { KeyBoard } from "../engine/keyboard";
+import { applyGameSettings } from "../engine/constants";
 
 const Index = ({ currentPage, setPage, playerId }) => {
     const [isConnectionEstablished, setConnectionEstablished] = useState(false);
@@ -22,9 +23,15 @@ const Index = ({ currentPage, setPage, playerId }) => {
         network.setOnHello((message) => {
             playerId.storeId(message.playerId);
             setConnectionEstablished(true);
+
+            const spectate = new URLSearchParams(window.location.search).get("spectate");
+            if (spectate) {
+                network.send(ClientMessages.Spectate(spectate));
+            }
         });
 
-        network.setOnGame(() => {
+        network.setOnGame((message) => {
+            applyGameSettings(message.settings);
             setPage(Pages.room);
         })
 
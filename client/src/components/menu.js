@@ -5,9 +5,27 @@ import { Constants } from "../engine/constants";
 import { ClientMessages } from "../protocol/messages";
 import { useAsyncError } from "../errorHandler";
 
+const RulesPresets = {
+    casual: "Casual",
+    ranked: "Ranked",
+    arcade: "Arcade",
+    crazy: "Crazy",
+    doubles: "Doubles 2v2",
+};
+
+// The bot a queued player gets when nobody comes, practice plays the normal one when none is picked.
+const BotDifficulties = {
+    "": "No bots",
+    easy: "Easy bot",
+    normal: "Normal bot",
+    hard: "Hard bot",
+};
+
 const Menu = ({ network, isConnectionEstablished }) => {
     const [playersOnline, setPlayersOnline] = useState(null);
     const [isFindingGame, setIsFindingGame] = useState(false);
+    const [rules, setRules] = useState("ranked");
+    const [bot, setBot] = useState("");
     const [latency, setLatency] = useState(0);
     const throwError = useAsyncError();
 
@@ -20,7 +38,7 @@ const Menu = ({ network, isConnectionEstablished }) => {
     const findGame = () => {
         try {
             if (!isFindingGame) {
-                network.send(ClientMessages.Queue());
+                network.send(ClientMessages.Queue(rules, bot));
                 setIsFindingGame(true);
             } else {
                 network.send(ClientMessages.UnQueue());
@@ -31,16 +49,24 @@ const Menu = ({ network, isConnectionEstablished }) => {
         }
     }
 
+    const practice = () => {
+        try {
+            network.send(ClientMessages.Bot(rules, bot || "normal"));
+        } catch (e) {
+            throwError(e);
+        }
+    }
+
     useEffect(() => {
         const graphics = new Graphics(canvasRef.current);
         graphics.drawField();
         graphics.drawPlayer(new Position(
             Constants.gameWidth / 2,
-            Constants.gameHeight - 2 * Constants.entityRadius,
+            Constants.gameHeight - 2 * Constants.malletRadius,
         ));
         graphics.drawPlayer(new Position(
             Constants.gameWidth / 2,
-            2 * Constants.entityRadius,
+            2 * Constants.malletRadius,
         ));
     }, []);
 
@@ -71,7 +97,14 @@ const Menu = ({ network, isConnectionEstablished }) => {
                 {isConnectionEstablished ? <div className="app__online-badge"></div> : ''}
                 Players online: {null !== playersOnline ? playersOnline : '...'}
                 </div>
+            <select className="app__select" value={rules} onChange={(e) => setRules(e.target.value)} disabled={isFindingGame}>
+                {Object.entries(RulesPresets).map(([name, title]) => <option key={name} value={name}>{title}</option>)}
+            </select>
+            <select className="app__select" value={bot} onChange={(e) => setBot(e.target.value)} disabled={isFindingGame}>
+                {Object.entries(BotDifficulties).map(([name, title]) => <option key={name} value={name}>{title}</option>)}
+            </select>
             <button className="app__button" onClick={findGame} disabled={!isConnectionEstablished}>{btnCap()}</button>
+            <button className="app__button" onClick={practice} disabled={!isConnectionEstablished || isFindingGame}>Practice vs bot</button>
             <canvas className="app__game" ref={canvasRef}></canvas>
         </div>
     )
@@ -8,15 +8,87 @@ const Constants = {
 
     gameWidth: 800,
     gameHeight: 1200,
+    offsetX: 0,
+    offsetY: 80,
     canvasWidth: 800 + 2 * 2,
     canvasHeight: 1200 + 80 * 2 + 2 * 2,
 
-    gatesWidth: 300,
-    gatesHeight: 80,
-    entityRadius: 40,
+    walls: [
+        [0, 0, 250, 0], [250, 0, 250, -80], [550, -80, 550, 0], [550, 0, 800, 0],
+        [800, 0, 800, 1200],
+        [800, 1200, 550, 1200], [550, 1200, 550, 1280], [250, 1280, 250, 1200], [250, 1200, 0, 1200],
+        [0, 1200, 0, 0],
+    ],
+    posts: [],
+    obstacles: [],
+    faceoff: [400, 600],
+    playerZone: [0, 600, 800, 1200],
+    opponentZone: [0, 0, 800, 600],
+    puckRadius: 40,
+    malletRadius: 40,
+    maxGoals: 10,
+    winBy: 1,
+    sets: 1,
+    timeLimit: 0,
+    overtime: false,
+    pauses: 2,
+    inputCycle: 20,
+    networkCycle: 20,
+    stuckRule: "award",
+    playerGoal: [250, 1240, 550, 1400],
+    opponentGoal: [250, -200, 550, -40],
+    powerUpRadius: 25,
+    noticeTime: 1500,
 
     socketPath: "/ws",
     socketPort: 3001,
 }
 
-export { Constants };
\ No newline at end of file
+const applyGameSettings = (settings) => {
+    if (!settings) {
+        return;
+    }
+
+    const rink = settings.rink;
+    const xs = [0, rink.width];
+    const ys = [0, rink.height];
+    rink.walls.forEach(([x1, y1, x2, y2]) => {
+        xs.push(x1, x2);
+        ys.push(y1, y2);
+    });
+
+    const minX = Math.min(...xs);
+    const minY = Math.min(...ys);
+
+    Constants.gameWidth = rink.width;
+    Constants.gameHeight = rink.height;
+    Constants.offsetX = -minX;
+    Constants.offsetY = -minY;
+    Constants.canvasWidth = Math.max(...xs) - minX + 2 * Constants.lineWidth;
+    Constants.canvasHeight = Math.max(...ys) - minY + 2 * Constants.lineWidth;
+
+    Constants.walls = rink.walls;
+    Constants.posts = rink.posts;
+    Constants.obstacles = rink.obstacles || [];
+    Constants.faceoff = rink.faceoff;
+    Constants.playerGoal = rink.playerGoal;
+    Constants.opponentGoal = rink.opponentGoal;
+    Constants.playerZone = rink.playerZone;
+    Constants.opponentZone = rink.opponentZone;
+    Constants.puckRadius = settings.puckRadius;
+    Constants.malletRadius = settings.malletRadius;
+    Constants.maxGoals = settings.maxGoals;
+    Constants.winBy = settings.winBy;
+    Constants.sets = settings.sets;
+    Constants.timeLimit = settings.timeLimit;
+    Constants.overtime = settings.overtime;
+    Constants.pauses = settings.pauses;
+    Constants.inputCycle = settings.inputCycle;
+    Constants.networkCycle = settings.networkCycle;
+    Constants.stuckRule = settings.stuckRule;
+    Constants.powerUpRadius = settings.powerUpRadius;
+    Constants.spectator = !!settings.spectator;
+    Constants.teamSize = settings.teamSize || 1;
+}
+
+export { Constants, applyGameSettings };
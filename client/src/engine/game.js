@@ -3,9 +3,37 @@ import { Constants } from "./constants";
 import { Position } from "./position";
 import { Utils } from "./utils";
 
-const FRAME_TIME = 20;
 const LERP_STEPS = 5;
 
+const EventSide = {
+    Player: "player",
+    Opponent: "opponent",
+    A: "a",
+    B: "b",
+};
+
+const SideNames = {
+    a: "Bottom",
+    b: "Top",
+};
+
+const PowerUpNames = {
+    grow: "Big mallet",
+    shrink: "Small goal",
+    freeze: "Freeze",
+    boost: "Speed boost",
+    ghost: "Ghost puck",
+};
+
+const ResultReasons = {
+    forfeit: "by forfeit",
+    disconnect: "by disconnect",
+    timeout: "on time",
+    admin: "stopped by the server",
+};
+
+const clockText = (seconds) => `${Math.floor(seconds / 60)}:${String(seconds % 60).padStart(2, "0")}`;
+
 export class Game {
     constructor(graphics, network) {
         this.graphics = graphics;
@@ -16,6 +44,19 @@ export class Game {
         this.setDefaultPositions();
         this.countA = 0;
         this.countB = 0;
+        this.obstacleOffsets = [];
+        this.modifiers = null;
+        this.powerUps = [];
+        this.effects = [];
+        this.match = null;
+        this.phase = "COUNTDOWN";
+        this.phaseLeft = 0;
+        this.pausesLeft = Constants.pauses;
+        this.possessionSide = null;
+        this.possessionLeft = 0;
+        this.notice = null;
+        this.noticeTime = 0;
+        this.spectators = 0;
         this.debug = false;
     }
 
@@ -24,26 +65,32 @@ export class Game {
     }
 
     setDefaultPositions() {
+        const [playerMinX, , playerMaxX, playerMaxY] = Constants.playerZone;
+        const [opponentMinX, opponentMinY, opponentMaxX] = Constants.opponentZone;
+
         this.playerPosition = new Position(
-            Constants.gameWidth / 2, 
-            Constants.gameHeight - 2 * Constants.entityRadius,
+            (playerMinX + playerMaxX) / 2, 
+            playerMaxY - 2 * Constants.malletRadius,
         );
 
-        this.opponentPosition = new Position(
-            Constants.gameWidth / 2, 
-            2 * Constants.entityRadius,
-        );
+        // Until the first world arrives there is one mallet a side, the player's own first.
+        this.self = 0;
+        this.malletTeams = [EventSide.Player, EventSide.Opponent];
+        this.malletPositions = [
+            structuredClone(this.playerPosition),
+            new Position(
+                (opponentMinX + opponentMaxX) / 2, 
+                opponentMinY + 2 * Constants.malletRadius,
+            ),
+        ];
 
-        this.puckPosition = new Position(
-            Constants.gameWidth / 2,
-            Constants.gameHeight / 2
-        )
+        this.puckPositions = [new Position(Constants.faceoff[0], Constants.faceoff[1])];
 
-        this.opponentPositionPrev = structuredClone(this.opponentPosition);
-        this.puckPositionPrev = structuredClone(this.puckPosition);
+        this.malletPositionsPrev = structuredClone(this.malletPositions);
+        this.puckPositionsPrev = structuredClone(this.puckPositions);
 
-        this.opponentPositionInter = structuredClone(this.opponentPosition);
-        this.puckPositionInter = structuredClone(this.puckPosition);
+        this.malletPositionsInter = structuredClone(this.malletPositions);
+        this.puckPositionsInter = structuredClone(this.puckPositions);
     }
 
     updatePlayerPosition(e) {
@@ -55,25 +102,32 @@ export class Game {
         );
 
         const gamePlayerPosition = Utils.convertToGameXY(mousePosition);
+        const [minX, minY, maxX, maxY] = Constants.playerZone;
 
         this.playerPosition.x = Math.round(Utils.clamp(
             gamePlayerPosition.x, 
-            Constants.entityRadius + Constants.lineWidth, 
-            Constants.gameWidth - Constants.entityRadius - Constants.lineWidth
+            minX + Constants.malletRadius + Constants.lineWidth, 
+            maxX - Constants.malletRadius - Constants.lineWidth
         ));
 
         this.playerPosition.y = Math.round(Utils.clamp(
             gamePlayerPosition.y, 
-            Constants.gameHeight / 2 + Constants.entityRadius + Constants.lineWidth,
-             Constants.gameHeight - Constants.entityRadius - Constants.lineWidth
+            minY + Constants.malletRadius + Constants.lineWidth,
+            maxY - Constants.malletRadius - Constants.lineWidth
         ));
     }
 
     run() {
+        this.updateTimer = setInterval(this.updateWorld.bind(this), Constants.networkCycle / LERP_STEPS);
+
+        // Spectators only watch, they have no mallet to move.
+        if (Constants.spectator) {
+            return;
+        }
+
         window.addEventListener("mouseenter", this.updatePlayerPosition.bind(this));
         window.addEventListener("mousemove", this.updatePlayerPosition.bind(this));
-        this.captureTimer = setInterval(this.capturePlayerInput.bind(this), FRAME_TIME);
-        this.updateTimer = setInterval(this.updateWorld.bind(this), FRAME_TIME / LERP_STEPS);
+        this.captureTimer = setInterval(this.capturePlayerInput.bind(this), Constants.inputCycle);
     }
 
     exit(reason) {
@@ -82,28 +136,248 @@ export class Game {
         this.network.send(ClientMessages.ExitGame(reason));
     }
 
+    togglePause() {
+        if (Constants.spectator) {
+            return;
+        }
+
+        this.network.send(this.phase === "PAUSED" ? ClientMessages.Resume() : ClientMessages.Pause());
+    }
+
     capturePlayerInput() {
         this.network.send(ClientMessages.PlayerAction(this.playerPosition));
     }
 
-    receiveWorld(opponentPosition, puckPosition, countA, countB) {
+    receiveWorld(self, mallets, pucks, countA, countB) {
         this.interpolateCounter = 0;
+        this.self = self;
+        this.malletTeams = mallets.map((mallet) => mallet.team);
 
-        this.opponentPositionPrev.x = this.opponentPosition.x;
-        this.opponentPositionPrev.y = this.opponentPosition.y;
-        this.puckPositionPrev.x = this.puckPosition.x;
-        this.puckPositionPrev.y = this.puckPosition.y;
+        if (mallets.length !== this.malletPositions.length) {
+            this.malletPositions = mallets.map((mallet) => structuredClone(mallet.position));
+            this.malletPositionsInter = structuredClone(this.malletPositions);
+        }
+
+        this.malletPositionsPrev = structuredClone(this.malletPositions);
+
+        if (pucks.length !== this.puckPositions.length) {
+            this.puckPositions = pucks.map((puck) => structuredClone(puck.position));
+            this.puckPositionsInter = structuredClone(this.puckPositions);
+        }
 
-        this.opponentPosition.x = opponentPosition.x;
-        this.opponentPosition.y = opponentPosition.y;
+        this.puckPositionsPrev = structuredClone(this.puckPositions);
 
-        this.puckPosition.x = puckPosition.x;
-        this.puckPosition.y = puckPosition.y;
+        mallets.forEach((mallet, i) => {
+            this.malletPositions[i].x = mallet.position.x;
+            this.malletPositions[i].y = mallet.position.y;
+        });
+
+        pucks.forEach((puck, i) => {
+            this.puckPositions[i].x = puck.position.x;
+            this.puckPositions[i].y = puck.position.y;
+        });
 
         this.countA = countA;
         this.countB = countB;
     }
 
+    receiveObstacles(offsets) {
+        this.obstacleOffsets = offsets;
+    }
+
+    receivePowerUps(modifiers, powerUps, effects) {
+        this.modifiers = modifiers;
+        this.powerUps = powerUps;
+        this.effects = effects;
+    }
+
+    receiveMatch(match) {
+        this.match = match;
+    }
+
+    receivePhase(phase, left) {
+        this.phase = phase;
+        this.phaseLeft = left;
+    }
+
+    receivePossession(side, left) {
+        this.possessionSide = side;
+        this.possessionLeft = left;
+    }
+
+    receiveSpectators(count) {
+        this.spectators = count;
+    }
+
+    receiveResult(result) {
+        this.result = result;
+    }
+
+    // exitText adds how the match ended, when it did, to the reason the game is over.
+    exitText(reason) {
+        if (!this.result) {
+            return reason;
+        }
+
+        return `${reason}. ${this.resultText(this.result)}`;
+    }
+
+    resultText(result) {
+        const parts = [`${this.winnerText(result.winner)} ${result.score[0]}:${result.score[1]}`];
+        if (ResultReasons[result.reason]) {
+            parts[0] += ` ${ResultReasons[result.reason]}`;
+        }
+
+        if (Constants.sets > 1) {
+            parts.push(`sets ${result.sets[0]}:${result.sets[1]}`);
+        }
+
+        parts.push(`played ${clockText(Math.round(result.duration / 1000))}`);
+
+        const self = result.players.find((player) => player.self);
+        if (self) {
+            parts.push(`your goals: ${self.goals}, hits: ${self.hits}`);
+        }
+
+        return parts.join(", ");
+    }
+
+    winnerText(winner) {
+        if (Constants.spectator) {
+            return SideNames[winner] ? `${SideNames[winner]} won` : "No winner";
+        }
+
+        switch (winner) {
+            case EventSide.Player:
+                return "You won";
+            case EventSide.Opponent:
+                return "You lost";
+            default:
+                return "No winner";
+        }
+    }
+
+    receiveEvent(eventType, side, powerUp) {
+        if (Constants.spectator) {
+            this.receiveSpectatorEvent(eventType, side, powerUp);
+
+            return;
+        }
+
+        switch (eventType) {
+            case "GOAL":
+                this.showNotice(side === EventSide.Player ? "Goal!" : "Goal conceded");
+                break;
+            case "STUCK_PUCK":
+                this.showNotice(this.stuckNotice(side));
+                break;
+            case "SERVE":
+                this.showNotice(side === EventSide.Player ? "Your serve" : "Opponent serves");
+                break;
+            case "FOUL":
+                this.showNotice(side === EventSide.Player ? "Foul: puck held too long" : "Opponent foul");
+                break;
+            case "POWERUP":
+                this.showNotice(`${side === EventSide.Player ? "You got" : "Opponent got"}: ${PowerUpNames[powerUp] || powerUp}`);
+                break;
+            case "OVERTIME":
+                this.showNotice("Overtime: next goal wins");
+                break;
+            case "SET_END":
+                this.showNotice(this.resultNotice(side, "set"));
+                break;
+            case "MATCH_END":
+                this.showNotice(this.resultNotice(side, "match"));
+                break;
+            case "PAUSE":
+                if (side === EventSide.Player) {
+                    this.pausesLeft--;
+                }
+
+                this.showNotice(side === EventSide.Player ? "Paused, press P to resume" : "Opponent paused, press P to resume");
+                break;
+            case "PAUSE_DENIED":
+                this.showNotice(this.pausesLeft > 0 ? "Can't pause now" : "No pauses left");
+                break;
+            case "RESUME":
+                this.showNotice(side === EventSide.Player ? "Waiting for opponent to resume" : "Opponent is ready to resume");
+                break;
+            case "DISCONNECTED":
+                this.showNotice("Opponent disconnected");
+                break;
+            case "RECONNECTED":
+                this.showNotice(side === EventSide.Player ? "Reconnected" : "Opponent is back");
+                break;
+        }
+    }
+
+    receiveSpectatorEvent(eventType, side, powerUp) {
+        const name = SideNames[side];
+
+        switch (eventType) {
+            case "GOAL":
+                this.showNotice(`${name} scores`);
+                break;
+            case "STUCK_PUCK":
+                this.showNotice("Puck stuck");
+                break;
+            case "SERVE":
+                this.showNotice(`${name} serves`);
+                break;
+            case "FOUL":
+                this.showNotice(`${name} foul`);
+                break;
+            case "POWERUP":
+                this.showNotice(`${name} got: ${PowerUpNames[powerUp] || powerUp}`);
+                break;
+            case "OVERTIME":
+                this.showNotice("Overtime: next goal wins");
+                break;
+            case "SET_END":
+                this.showNotice(name ? `${name} wins the set` : "The set is a draw");
+                break;
+            case "MATCH_END":
+                this.showNotice(name ? `${name} wins the match` : "The match is a draw");
+                break;
+            case "PAUSE":
+                this.showNotice(`${name} paused`);
+                break;
+            case "DISCONNECTED":
+                this.showNotice(`${name} disconnected`);
+                break;
+            case "RECONNECTED":
+                this.showNotice(`${name} is back`);
+                break;
+        }
+    }
+
+    resultNotice(side, what) {
+        switch (side) {
+            case EventSide.Player:
+                return `You won the ${what}`;
+            case EventSide.Opponent:
+                return `You lost the ${what}`;
+            default:
+                return `The ${what} is a draw`;
+        }
+    }
+
+    stuckNotice(side) {
+        switch (Constants.stuckRule) {
+            case "nudge":
+                return "Puck stuck, nudged free";
+            case "award":
+                return side === EventSide.Player ? "Puck stuck, given to opponent" : "Puck stuck, your puck";
+            default:
+                return "Puck stuck, restart";
+        }
+    }
+
+    showNotice(text) {
+        this.notice = text;
+        this.noticeTime = Date.now();
+    }
+
     interpolatePositions() {
         if (this.interpolateCounter > LERP_STEPS) {
             return;
@@ -112,11 +386,15 @@ export class Game {
         this.interpolateCounter++;
         let stepSize = this.interpolateCounter / LERP_STEPS;
 
-        this.opponentPositionInter.x = Utils.lerp(this.opponentPositionPrev.x, this.opponentPosition.x, stepSize);
-        this.opponentPositionInter.y = Utils.lerp(this.opponentPositionPrev.y, this.opponentPosition.y, stepSize);
+        this.malletPositions.forEach((position, i) => {
+            this.malletPositionsInter[i].x = Utils.lerp(this.malletPositionsPrev[i].x, position.x, stepSize);
+            this.malletPositionsInter[i].y = Utils.lerp(this.malletPositionsPrev[i].y, position.y, stepSize);
+        });
 
-        this.puckPositionInter.x = Utils.lerp(this.puckPositionPrev.x, this.puckPosition.x, stepSize);
-        this.puckPositionInter.y = Utils.lerp(this.puckPositionPrev.y, this.puckPosition.y, stepSize);
+        this.puckPositions.forEach((position, i) => {
+            this.puckPositionsInter[i].x = Utils.lerp(this.puckPositionsPrev[i].x, position.x, stepSize);
+            this.puckPositionsInter[i].y = Utils.lerp(this.puckPositionsPrev[i].y, position.y, stepSize);
+        });
     }
 
     updateWorld() {
@@ -124,16 +402,88 @@ export class Game {
 
         this.graphics.clear();
         this.graphics.drawField();
-        this.graphics.drawPlayer(this.playerPosition);
-        this.graphics.drawPlayer(this.opponentPositionInter);
-        this.graphics.drawPuck(this.puckPositionInter);
+        this.graphics.drawObstacles(this.obstacleOffsets);
+        this.powerUps.forEach((powerUp) => this.graphics.drawPowerUp(powerUp.position, powerUp.type));
+
+        const modifiers = this.modifiers;
+        if (modifiers) {
+            this.graphics.drawGoalShields(Constants.playerGoal, modifiers.playerGoalScale);
+            this.graphics.drawGoalShields(Constants.opponentGoal, modifiers.opponentGoalScale);
+        }
+
+        this.malletPositionsInter.forEach((position, i) => {
+            const ownTeam = this.malletTeams[i] === EventSide.Player || this.malletTeams[i] === EventSide.A;
+            const radius = modifiers && (ownTeam ? modifiers.playerRadius : modifiers.opponentRadius);
+
+            this.graphics.drawPlayer(position, radius, i === this.self && Constants.teamSize > 1);
+        });
+
+        // The opponent's ghost effect leaves the pucks barely visible.
+        const ghost = this.effects.some((effect) => effect.type === "ghost" && effect.side === EventSide.Opponent);
+        this.puckPositionsInter.forEach((position) => this.graphics.drawPuck(position, ghost));
         this.graphics.drawGoals(this.countA, this.countB);
+        if (this.match) {
+            this.graphics.drawMatch(this.matchText(this.match));
+        }
+        this.graphics.drawEffects(this.effects.map((effect) =>
+            `${this.effectOwner(effect.side)}${PowerUpNames[effect.type] || effect.type} ${Math.ceil(effect.timeLeft / 1000)}`
+        ));
+
+        if (this.possessionSide && this.possessionSide !== "none") {
+            this.graphics.drawPossession(
+                this.possessionLeft,
+                this.possessionSide === EventSide.Player || this.possessionSide === EventSide.A
+            );
+        }
+
+        if (this.phase === "COUNTDOWN" || this.phase === "RESUME") {
+            this.graphics.drawCountdown(Math.ceil(this.phaseLeft / 1000));
+        }
+
+        if (this.phase === "PAUSED") {
+            this.graphics.drawCountdown(`Paused ${Math.ceil(this.phaseLeft / 1000)}`);
+        }
+
+        if (this.phase === "RECONNECT") {
+            this.graphics.drawNotice(`Waiting for reconnect ${Math.ceil(this.phaseLeft / 1000)}`);
+        }
+
+        if (this.notice && Date.now() - this.noticeTime < Constants.noticeTime) {
+            this.graphics.drawNotice(this.notice);
+        }
 
         if (this.debug) {
             this.drawDebug();
         }
     }
 
+    effectOwner(side) {
+        if (Constants.spectator) {
+            return `${SideNames[side]}: `;
+        }
+
+        return side === EventSide.Player ? "" : "Opponent: ";
+    }
+
+    matchText(match) {
+        const parts = [];
+        if (Constants.sets > 1) {
+            parts.push(`Set ${match.set} (${match.setsPlayer}:${match.setsOpponent})`);
+        }
+
+        if (match.overtime) {
+            parts.push("OT");
+        } else if (Constants.timeLimit > 0) {
+            parts.push(clockText(Math.ceil(match.clock / 1000)));
+        }
+
+        if (this.spectators > 0) {
+            parts.push(`Watching: ${this.spectators}`);
+        }
+
+        return parts.join(" ");
+    }
+
     drawDebug() {
         this.graphics.drawText(
             `A:${this.playerPosition.x}:${this.playerPosition.y}`,
@@ -141,14 +491,15 @@ export class Game {
             Constants.secondaryFont
         );
 
+        const opponents = this.malletPositionsInter.filter((position, i) => this.malletTeams[i] !== EventSide.Player);
         this.graphics.drawText(
-            `B:${this.opponentPositionInter.x}:${this.opponentPositionInter.y}`,
+            opponents.map((position) => `B:${position.x}:${position.y}`).join(' '),
             Utils.convertToCanvasXY(new Position(20, Constants.fontSize)),
             Constants.secondaryFont
         );
 
         this.graphics.drawText(
-            `Puck:${this.puckPositionInter.x}:${this.puckPositionInter.y}`, 
+            this.puckPositionsInter.map((position) => `Puck:${position.x}:${position.y}`).join(' '), 
             Utils.convertToCanvasXY(new Position(20, Constants.gameHeight / 2 + Constants.fontSize / 2)),
             Constants.secondaryFont,
         );
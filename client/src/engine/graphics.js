@@ -25,43 +25,120 @@ export class Graphics {
 
     drawField() {
         this.context.beginPath();
-        const leftTop = Utils.convertToCanvasXY(new Position(0, 0));
-        const leftTopGate = Utils.convertToCanvasXY(new Position(Constants.gameWidth / 2 - Constants.gatesWidth / 2, 0));
-        const rightTopGate = Utils.convertToCanvasXY(new Position(Constants.gameWidth / 2 + Constants.gatesWidth / 2, 0));
-        const rightTop = Utils.convertToCanvasXY(new Position(Constants.gameWidth, 0));
-        const leftBottom = Utils.convertToCanvasXY(new Position(0, Constants.gameHeight));
-        const leftBottomGate = Utils.convertToCanvasXY(new Position(Constants.gameWidth / 2 - Constants.gatesWidth / 2, Constants.gameHeight));
-        const rightBottomGate = Utils.convertToCanvasXY(new Position(Constants.gameWidth / 2 + Constants.gatesWidth / 2, Constants.gameHeight));
-        const rightBottom = Utils.convertToCanvasXY(new Position(Constants.gameWidth, Constants.gameHeight));
-
-        this.context.moveTo(leftTop.x, leftTop.y);
-        this.context.lineTo(leftTopGate.x, leftTopGate.y);
-        this.context.moveTo(rightTopGate.x, rightTopGate.y);
-        this.context.lineTo(rightTop.x, rightTop.y);
-        this.context.lineTo(rightBottom.x, rightBottom.y);
-        this.context.lineTo(rightBottomGate.x, rightBottomGate.y);
-        this.context.moveTo(leftBottomGate.x, leftBottomGate.y);
-        this.context.lineTo(leftBottom.x, leftBottom.y);
-        this.context.lineTo(leftTop.x, leftTop.y);
+
+        Constants.walls.forEach(([x1, y1, x2, y2]) => {
+            const start = Utils.convertToCanvasXY(new Position(x1, y1));
+            const end = Utils.convertToCanvasXY(new Position(x2, y2));
+
+            this.context.moveTo(start.x, start.y);
+            this.context.lineTo(end.x, end.y);
+        });
 
         this.context.stroke();
+
+        Constants.posts.forEach(([x, y, radius]) => {
+            const center = Utils.convertToCanvasXY(new Position(x, y));
+
+            this.context.beginPath();
+            this.context.arc(center.x, center.y, radius, 0, 2 * Math.PI);
+            this.context.fill();
+        });
+    }
+
+    drawObstacles(offsets) {
+        Constants.obstacles.forEach((obstacle, i) => {
+            const offset = offsets[i] || new Position(0, 0);
+
+            this.context.beginPath();
+            if (obstacle.type === "bumper") {
+                const center = Utils.convertToCanvasXY(new Position(obstacle.center[0] + offset.x, obstacle.center[1] + offset.y));
+
+                this.context.arc(center.x, center.y, obstacle.radius, 0, 2 * Math.PI);
+            } else {
+                const start = Utils.convertToCanvasXY(new Position(obstacle.from[0] + offset.x, obstacle.from[1] + offset.y));
+                const end = Utils.convertToCanvasXY(new Position(obstacle.to[0] + offset.x, obstacle.to[1] + offset.y));
+
+                this.context.moveTo(start.x, start.y);
+                this.context.lineTo(end.x, end.y);
+            }
+            this.context.stroke();
+        });
     }
 
-    drawPlayer(position) {
+    drawPlayer(position, radius, own) {
         const canvasPosition = Utils.convertToCanvasXY(position);
 
         this.context.beginPath();
-        this.context.arc(canvasPosition.x, canvasPosition.y, Constants.entityRadius, 0, 2 * Math.PI);
+        this.context.arc(canvasPosition.x, canvasPosition.y, radius || Constants.malletRadius, 0, 2 * Math.PI);
         this.context.stroke();
+
+        // Teammates look alike, so the player's own mallet gets a mark.
+        if (own) {
+            this.context.beginPath();
+            this.context.arc(canvasPosition.x, canvasPosition.y, (radius || Constants.malletRadius) / 3, 0, 2 * Math.PI);
+            this.context.fill();
+        }
     }
 
-    drawPuck(position) {
+    drawPuck(position, ghost) {
         const canvasPosition = Utils.convertToCanvasXY(position);
 
+        this.context.globalAlpha = ghost ? 0.1 : 1;
         this.context.beginPath();
-        this.context.arc(canvasPosition.x, canvasPosition.y, Constants.entityRadius, 0, 2 * Math.PI);
+        this.context.arc(canvasPosition.x, canvasPosition.y, Constants.puckRadius, 0, 2 * Math.PI);
         this.context.fill();
         this.context.stroke();
+        this.context.globalAlpha = 1;
+    }
+
+    drawPowerUp(position, type) {
+        const canvasPosition = Utils.convertToCanvasXY(position);
+
+        this.context.beginPath();
+        this.context.setLineDash([4, 4]);
+        this.context.arc(canvasPosition.x, canvasPosition.y, Constants.powerUpRadius, 0, 2 * Math.PI);
+        this.context.stroke();
+        this.context.setLineDash([]);
+
+        this.drawText(
+            type.charAt(0).toUpperCase(),
+            new Position(canvasPosition.x, canvasPosition.y + Constants.secondaryFontSize / 3),
+            Constants.secondaryFont,
+            "center"
+        );
+    }
+
+    // drawGoalShields draws the closed outer parts of a shrunken goal mouth.
+    drawGoalShields(goal, scale) {
+        if (!goal || scale >= 1) {
+            return;
+        }
+
+        const [minX, minY, maxX, maxY] = goal;
+        const cut = (maxX - minX) * (1 - scale) / 2;
+        const y = maxY < Constants.faceoff[1] ? maxY : minY;
+
+        this.context.beginPath();
+        [[minX, minX + cut], [maxX - cut, maxX]].forEach(([from, to]) => {
+            const start = Utils.convertToCanvasXY(new Position(from, y));
+            const end = Utils.convertToCanvasXY(new Position(to, y));
+
+            this.context.moveTo(start.x, start.y);
+            this.context.lineTo(end.x, end.y);
+        });
+        this.context.lineWidth = 3 * Constants.lineWidth;
+        this.context.stroke();
+        this.context.lineWidth = Constants.lineWidth;
+    }
+
+    drawEffects(lines) {
+        lines.forEach((line, i) => {
+            this.drawText(
+                line,
+                new Position(Constants.secondaryFontSize / 2, Constants.canvasHeight / 2 + (i + 2) * Constants.secondaryFontSize),
+                Constants.secondaryFont
+            );
+        });
     }
 
     drawGoals(countA, countB) {
@@ -69,6 +146,43 @@ export class Graphics {
         this.drawText(countB, new Position(Constants.canvasWidth / 2, Constants.fontSize), Constants.font, "center");
     }
 
+    drawMatch(text) {
+        this.drawText(
+            text,
+            new Position(Constants.secondaryFontSize / 2, Constants.secondaryFontSize),
+            Constants.secondaryFont
+        );
+    }
+
+    drawPossession(left, isPlayer) {
+        const y = isPlayer ? Constants.canvasHeight * 3 / 4 : Constants.canvasHeight / 4;
+
+        this.drawText(
+            Math.ceil(left / 1000),
+            new Position(Constants.canvasWidth - Constants.secondaryFontSize, y),
+            Constants.secondaryFont,
+            "center"
+        );
+    }
+
+    drawCountdown(seconds) {
+        this.drawText(
+            seconds,
+            new Position(Constants.canvasWidth / 2, Constants.canvasHeight / 2 + Constants.fontSize * 1.5),
+            Constants.font,
+            "center"
+        );
+    }
+
+    drawNotice(text) {
+        this.drawText(
+            text,
+            new Position(Constants.canvasWidth / 2, Constants.canvasHeight / 2 - Constants.secondaryFontSize),
+            Constants.secondaryFont,
+            "center"
+        );
+    }
+
     drawText(text, position, font, align) {
         this.context.font = font || Constants.font;
         this.context.textAlign = align || "start";
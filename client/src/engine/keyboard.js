@@ -3,11 +3,19 @@ export class KeyBoard {
         this.debugHangler = handler;
     }
 
+    onPauseHandler(handler) {
+        this.pauseHandler = handler;
+    }
+
     setHandlers() {
         document.onkeyup = ((e) => {
             if (e.code == 'KeyO') {
                 this.debugHangler && this.debugHangler()
             }
+
+            if (e.code == 'KeyP') {
+                this.pauseHandler && this.pauseHandler()
+            }
         })
     }
 }
\ No newline at end of file
@@ -37,6 +37,18 @@ export class Network {
         this.messageHandlers.set(MessageType.ExitGame, onExitGame)
     }
 
+    setOnEvent(onEvent) {
+        this.messageHandlers.set(MessageType.Event, onEvent)
+    }
+
+    setOnSpectators(onSpectators) {
+        this.messageHandlers.set(MessageType.Spectators, onSpectators)
+    }
+
+    setOnResult(onResult) {
+        this.messageHandlers.set(MessageType.Result, onResult)
+    }
+
     openConnection() {
         this.socket = new WebSocket(this.url());
 
@@ -4,15 +4,15 @@ import { Position } from "./position";
 const Utils = {
     convertToCanvasXY: (gamePosition) => {
         return new Position(
-            gamePosition.x + Constants.lineWidth / 2,
-            gamePosition.y + Constants.gatesHeight + Constants.lineWidth / 2
+            gamePosition.x + Constants.offsetX + Constants.lineWidth / 2,
+            gamePosition.y + Constants.offsetY + Constants.lineWidth / 2
         );
     },
 
     convertToGameXY: (canvasPosition) => {
         return new Position(
-            canvasPosition.x - Constants.lineWidth / 2,
-            canvasPosition.y - Constants.gatesHeight - Constants.lineWidth / 2
+            canvasPosition.x - Constants.offsetX - Constants.lineWidth / 2,
+            canvasPosition.y - Constants.offsetY - Constants.lineWidth / 2
         );
     },
 
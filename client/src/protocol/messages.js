@@ -11,6 +11,13 @@ const MessageType = {
     ExitGame: "EXITGAME",
     World: "WORLD",
     PlayerAction: "PLAYERACTION",
+    Event: "EVENT",
+    Pause: "PAUSE",
+    Resume: "RESUME",
+    Spectate: "SPECTATE",
+    Spectators: "SPECTATORS",
+    Bot: "BOT",
+    Result: "RESULT",
 };
 
 const ServerMessages = {
@@ -33,19 +40,29 @@ const ServerMessages = {
             type: MessageType.Online,
         };
     },
-    Game: (roomId) => {
+    Game: (roomId, settings) => {
         return {
             roomId,
+            settings,
             type: MessageType.Game,
         } 
     },
-    World: (playerPosition, opponentPosition, puckPosition, countA, countB) => {
+    World: (self, mallets, pucks, obstacles, countA, countB, possessionSide, possessionLeft, modifiers, powerUps, effects, match, phase, phaseLeft) => {
         return {
-            playerPosition,
-            opponentPosition,
-            puckPosition,
+            self,
+            mallets,
+            pucks,
+            obstacles,
             countA,
             countB,
+            possessionSide,
+            possessionLeft,
+            modifiers,
+            powerUps,
+            effects,
+            match,
+            phase,
+            phaseLeft,
             type: MessageType.World,
         };
     },
@@ -55,6 +72,26 @@ const ServerMessages = {
             type:MessageType.ExitGame,
         };
     },
+    Event: (eventType, side, powerUp) => {
+        return {
+            eventType,
+            side,
+            powerUp,
+            type: MessageType.Event,
+        };
+    },
+    Spectators: (count) => {
+        return {
+            count: Number(count),
+            type: MessageType.Spectators,
+        };
+    },
+    Result: (result) => {
+        return {
+            result,
+            type: MessageType.Result,
+        };
+    },
     parse(body) {
         let parts = body.split(':');
         if (parts.length <= 0) {
@@ -71,26 +108,122 @@ const ServerMessages = {
             case MessageType.Online: 
                 return this.Online(parts.shift());
             case MessageType.Game: 
-                return this.Game(parts.shift());
+                const roomId = parts.shift();
+                const settings = parts.length > 0 ? JSON.parse(parts.join(':')) : null;
+
+                return this.Game(roomId, settings);
             case MessageType.ExitGame:
                 return this.ExitGame(parts.shift())
             case MessageType.World:
-                const playerX = parts.shift();
-                const playerY = parts.shift();
-                const opponentX = parts.shift();
-                const opponentY = parts.shift();
-                const puckX = parts.shift();
-                const puckY = parts.shift();
+                const self = Number(parts.shift());
+                const malletCount = Number(parts.shift());
+
+                const mallets = [];
+                for (let i = 0; i < malletCount; i++) {
+                    const malletX = parts.shift();
+                    const malletY = parts.shift();
+                    const malletTeam = parts.shift();
+
+                    mallets.push({
+                        position: new Position(Number(malletX), Number(malletY)),
+                        team: malletTeam,
+                    });
+                }
+
                 const countA = parts.shift();
                 const countB = parts.shift();
+                const possessionSide = parts.shift();
+                const possessionLeft = parts.shift();
+                const puckCount = Number(parts.shift());
+
+                const pucks = [];
+                for (let i = 0; i < puckCount; i++) {
+                    const puckX = parts.shift();
+                    const puckY = parts.shift();
+                    const puckSpin = parts.shift();
+
+                    pucks.push({
+                        position: new Position(Number(puckX), Number(puckY)),
+                        spin: Number(puckSpin),
+                    });
+                }
+
+                const obstacleCount = Number(parts.shift());
+                const obstacles = [];
+                for (let i = 0; i < obstacleCount; i++) {
+                    const offsetX = parts.shift();
+                    const offsetY = parts.shift();
+
+                    obstacles.push(new Position(Number(offsetX), Number(offsetY)));
+                }
+
+                const modifiers = {
+                    playerRadius: Number(parts.shift()),
+                    opponentRadius: Number(parts.shift()),
+                    playerGoalScale: Number(parts.shift()),
+                    opponentGoalScale: Number(parts.shift()),
+                };
+
+                const powerUpCount = Number(parts.shift());
+                const powerUps = [];
+                for (let i = 0; i < powerUpCount; i++) {
+                    const powerUpType = parts.shift();
+                    const powerUpX = parts.shift();
+                    const powerUpY = parts.shift();
+
+                    powerUps.push({
+                        type: powerUpType,
+                        position: new Position(Number(powerUpX), Number(powerUpY)),
+                    });
+                }
+
+                const effectCount = Number(parts.shift());
+                const effects = [];
+                for (let i = 0; i < effectCount; i++) {
+                    const effectType = parts.shift();
+                    const effectSide = parts.shift();
+                    const effectLeft = parts.shift();
+
+                    effects.push({
+                        type: effectType,
+                        side: effectSide,
+                        timeLeft: Number(effectLeft),
+                    });
+                }
+
+                const match = {
+                    clock: Number(parts.shift()),
+                    set: Number(parts.shift()),
+                    setsPlayer: Number(parts.shift()),
+                    setsOpponent: Number(parts.shift()),
+                    overtime: parts.shift() === "1",
+                };
+
+                const phase = parts.shift();
+                const phaseLeft = parts.shift();
 
                 return this.World(
-                    new Position(Number(playerX), Number(playerY)), 
-                    new Position(Number(opponentX), Number(opponentY)), 
-                    new Position(Number(puckX), Number(puckY)),
+                    self,
+                    mallets,
+                    pucks,
+                    obstacles,
                     countA,
-                    countB
+                    countB,
+                    possessionSide,
+                    Number(possessionLeft),
+                    modifiers,
+                    powerUps,
+                    effects,
+                    match,
+                    phase,
+                    Number(phaseLeft)
                 );
+            case MessageType.Event:
+                return this.Event(parts.shift(), parts.shift(), parts.shift());
+            case MessageType.Spectators:
+                return this.Spectators(parts.shift());
+            case MessageType.Result:
+                return this.Result(JSON.parse(parts.join(':')));
             default: return null
         }
     }
@@ -105,10 +238,12 @@ const ClientMessages = {
             }
         };
     },
-    Queue: () => {
+    Queue: (rules, bot) => {
         return {
+            rules,
+            bot,
             stringify: () => {
-                return `${MessageType.Queue}`;
+                return [MessageType.Queue, rules, bot].filter(Boolean).join(':');
             }
         };
     },
@@ -150,6 +285,37 @@ const ClientMessages = {
                 return `${MessageType.ExitGame}:${reason}`;
             }
         };
+    },
+    Pause: () => {
+        return {
+            stringify: () => {
+                return `${MessageType.Pause}`;
+            }
+        };
+    },
+    Resume: () => {
+        return {
+            stringify: () => {
+                return `${MessageType.Resume}`;
+            }
+        };
+    },
+    Spectate: (roomId) => {
+        return {
+            roomId,
+            stringify: () => {
+                return `${MessageType.Spectate}:${roomId}`;
+            }
+        };
+    },
+    Bot: (rules, difficulty) => {
+        return {
+            rules,
+            difficulty,
+            stringify: () => {
+                return `${MessageType.Bot}:${rules || ''}:${difficulty || ''}`;
+            }
+        };
     }
 }
 
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"blindwizard.ru/hockey/engine"
+	"github.com/google/uuid"
+)
+
+const BOT_EASY = "easy"
+const BOT_NORMAL = "normal"
+const BOT_HARD = "hard"
+const DEFAULT_BOT = BOT_NORMAL
+
+const BOT_WRITE_BUFFER = 16
+const BOT_REQUEST_BUFFER = 16
+const BOT_PREDICTION_TIME = 3000
+const BOT_THREAT_SPEED = 0.05
+
+// BotDifficulty is how well a bot plays. It acts on the pucks as they were ReactionDelay ago,
+// moves its mallet at most MaxSpeed pixels a millisecond and misses where it aims by up to AimError radians.
+// The more Aggressiveness, from 0 to 1, the further from its goal it goes for the puck and the harder it hits.
+type BotDifficulty struct {
+	ReactionDelay  time.Duration
+	MaxSpeed       float64
+	AimError       float64
+	Aggressiveness float64
+}
+
+var botDifficulties = map[string]BotDifficulty{
+	BOT_EASY:   {ReactionDelay: 250 * time.Millisecond, MaxSpeed: 0.8, AimError: 0.4, Aggressiveness: 0.3},
+	BOT_NORMAL: {ReactionDelay: 150 * time.Millisecond, MaxSpeed: 1.5, AimError: 0.2, Aggressiveness: 0.6},
+	BOT_HARD:   {ReactionDelay: 60 * time.Millisecond, MaxSpeed: 2.5, AimError: 0.05, Aggressiveness: 0.9},
+}
+
+type botSnapshot struct {
+	at     time.Time
+	mallet engine.Vector
+	pucks  []engine.Vector
+}
+
+// Bot takes a seat in a room like a connected player, it reads the messages sent to its player
+// and moves with the same messages a client sends, so it sees the rink from its own end.
+// Target is the puck it is going for and aim how far off it shoots that puck.
+type Bot struct {
+	player     *Player
+	difficulty BotDifficulty
+	settings   GameSettings
+	walls      []engine.Wall
+	history    []botSnapshot
+	target     int
+	aim        float64
+	random     *rand.Rand
+}
+
+// NewBot makes a bot of the difficulty, an unknown one plays at the default.
+func NewBot(difficulty string) *Bot {
+	level, exists := botDifficulties[difficulty]
+	if !exists {
+		level = botDifficulties[DEFAULT_BOT]
+	}
+
+	player := NewPlayer(uuid.New(), uuid.Nil)
+	player.bot = true
+	player.SetExchange(make(chan ClientMessage, 1), make(chan ServerMessage, BOT_WRITE_BUFFER))
+
+	return &Bot{
+		player:     player,
+		difficulty: level,
+		target:     -1,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Run plays until the bot's room closes.
+func (bot *Bot) Run() {
+	defer bot.player.Disconnect()
+
+	for message := range bot.player.GetWrite() {
+		switch message := message.(type) {
+		case GameMessage:
+			bot.setup(message.settings)
+		case WorldMessage:
+			bot.play(message)
+		case ExitGameMessage:
+			return
+		}
+	}
+}
+
+func (bot *Bot) setup(settings GameSettings) {
+	bot.settings = settings
+	bot.history = bot.history[:0]
+	bot.walls = bot.walls[:0]
+
+	for _, wall := range settings.Rink.Walls {
+		bot.walls = append(bot.walls, engine.Wall{Start: engine.NewVector(wall[0], wall[1]), End: engine.NewVector(wall[2], wall[3])})
+	}
+}
+
+// play remembers the world and answers it as it was a reaction delay ago,
+// only its own mallet the bot always knows where it is.
+func (bot *Bot) play(world WorldMessage) {
+	if world.self < 0 || world.self >= len(world.mallets) {
+		return
+	}
+
+	snapshot := botSnapshot{at: time.Now(), mallet: positionVector(world.mallets[world.self].position)}
+	for _, puck := range world.pucks {
+		snapshot.pucks = append(snapshot.pucks, positionVector(puck.position))
+	}
+
+	bot.history = append(bot.history, snapshot)
+
+	seen := -1
+	for i, remembered := range bot.history {
+		if snapshot.at.Sub(remembered.at) >= bot.difficulty.ReactionDelay {
+			seen = i
+		}
+	}
+
+	if seen < 1 {
+		return
+	}
+
+	bot.history = slices.Delete(bot.history, 0, seen-1)
+	if world.phase != PHASE_LIVE {
+		return
+	}
+
+	radius := float64(world.modifiersA.MalletRadius)
+	bot.move(snapshot.mallet, bot.decide(bot.history[0], bot.history[1], snapshot.mallet, radius), radius)
+}
+
+// decide picks where the mallet heads: behind a puck in reach to shoot it at the opponent's goal,
+// in the way of a puck coming at its own goal or back in front of that goal.
+func (bot *Bot) decide(before botSnapshot, now botSnapshot, mallet engine.Vector, radius float64) engine.Vector {
+	zone := describedZone(bot.settings.Rink.PlayerZone)
+	goal := describedZone(bot.settings.Rink.PlayerGoal).Center()
+	puckRadius := float64(bot.settings.PuckRadius)
+	elapsed := float64(now.at.Sub(before.at).Milliseconds())
+
+	guard := engine.NewVector(goal.X, zone.Max.Y-2*radius)
+	reach := engine.Lerp(zone.Max.Y, zone.Min.Y, bot.difficulty.Aggressiveness)
+
+	threat, threatTime, threatPoint := -1, math.Inf(1), guard
+	nearest, nearestDistance := -1, math.Inf(1)
+
+	for i, puck := range now.pucks {
+		magnitude := engine.NewVector(0, 0)
+		if len(before.pucks) == len(now.pucks) && elapsed > 0 {
+			magnitude = engine.MultiplyVectorNumber(engine.SubstractVectors(puck, before.pucks[i]), 1/elapsed)
+		}
+
+		// The bot's own goal is always at the bottom of what it sees.
+		if magnitude.Y > BOT_THREAT_SPEED {
+			path := engine.PredictPath(bot.walls, puckRadius, puck, magnitude, BOT_PREDICTION_TIME)
+			if point, distance, crosses := crossing(path, guard.Y); crosses && distance/engine.VectorLength(magnitude) < threatTime {
+				threat, threatTime, threatPoint = i, distance/engine.VectorLength(magnitude), point
+			}
+		}
+
+		// A slow puck in its half is always the bot's to take, or it would be held there.
+		if zone.Contains(puck) && (puck.Y >= reach || engine.VectorLength(magnitude) < BOT_THREAT_SPEED) {
+			if distance := engine.DistanceBetweenPoints(puck, mallet); distance < nearestDistance {
+				nearest, nearestDistance = i, distance
+			}
+		}
+	}
+
+	switch {
+	case nearest >= 0 && (threat < 0 || threat == nearest):
+		return bot.shoot(now.pucks[nearest], nearest, mallet, radius)
+	case threat >= 0:
+		bot.target = -1
+
+		return engine.NewVector(threatPoint.X, guard.Y)
+	default:
+		bot.target = -1
+
+		return guard
+	}
+}
+
+// shoot gets behind the puck and strikes through it once lined up,
+// the aim is picked once for every puck the bot goes for.
+func (bot *Bot) shoot(puck engine.Vector, index int, mallet engine.Vector, radius float64) engine.Vector {
+	if bot.target != index {
+		bot.target = index
+		bot.aim = (bot.random.Float64()*2 - 1) * bot.difficulty.AimError
+	}
+
+	opponentGoal := describedZone(bot.settings.Rink.OpponentGoal).Center()
+	direction := rotateVector(engine.NormalizeVector(engine.SubstractVectors(opponentGoal, puck)), bot.aim)
+	across := engine.NewVector(-direction.Y, direction.X)
+	contact := radius + float64(bot.settings.PuckRadius)
+
+	toPuck := engine.SubstractVectors(puck, mallet)
+	along, aside := engine.MultiplyVectors(toPuck, direction), engine.MultiplyVectors(toPuck, across)
+
+	if along > 0 && math.Abs(aside) < radius/2 {
+		return engine.SumVectors(puck, engine.MultiplyVectorNumber(direction, 2*contact*bot.difficulty.Aggressiveness))
+	}
+
+	behind := engine.SubstractVectors(puck, engine.MultiplyVectorNumber(direction, contact+radius/2))
+	if along <= 0 {
+		// Ahead of the puck it goes round it rather than knocking it back.
+		return engine.SubstractVectors(behind, engine.MultiplyVectorNumber(across, math.Copysign(contact, aside)))
+	}
+
+	return behind
+}
+
+// move heads for the target no faster than the bot may, inside the mallet's zone.
+// A move the room is not ready for is dropped, the next world brings another.
+func (bot *Bot) move(mallet engine.Vector, target engine.Vector, radius float64) {
+	step := bot.difficulty.MaxSpeed * float64(bot.settings.NetworkCycle)
+	if toTarget := engine.SubstractVectors(target, mallet); engine.VectorLength(toTarget) > step {
+		target = engine.SumVectors(mallet, engine.ResizeVector(toTarget, step))
+	}
+
+	position := describedZone(bot.settings.Rink.PlayerZone).Clamp(engine.RoundPosition(target), int(radius))
+
+	select {
+	case bot.player.GetInput() <- NewPlayerActionMessage(position.X, position.Y):
+	default:
+	}
+}
+
+// crossing finds where the path first reaches the height y and how long the path is up to there.
+func crossing(path []engine.Vector, y float64) (engine.Vector, float64, bool) {
+	length := 0.0
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		if (from.Y-y)*(to.Y-y) <= 0 && from.Y != to.Y {
+			point := engine.LerpVector(from, to, (y-from.Y)/(to.Y-from.Y))
+
+			return point, length + engine.DistanceBetweenPoints(from, point), true
+		}
+
+		length += engine.DistanceBetweenPoints(from, to)
+	}
+
+	return engine.Vector{}, 0, false
+}
+
+func rotateVector(vector engine.Vector, angle float64) engine.Vector {
+	sin, cos := math.Sincos(angle)
+
+	return engine.NewVector(vector.X*cos-vector.Y*sin, vector.X*sin+vector.Y*cos)
+}
+
+func positionVector(position engine.Position) engine.Vector {
+	return engine.NewVector(float64(position.X), float64(position.Y))
+}
+
+func describedZone(zone [4]float64) engine.Zone {
+	return engine.Zone{Min: engine.NewVector(zone[0], zone[1]), Max: engine.NewVector(zone[2], zone[3])}
+}
+
+// botRequest is a player who wants to play bots, matchmaking starts every match from its own loop.
+type botRequest struct {
+	player     *Player
+	rules      string
+	difficulty string
+}
+
+// StartBotMatch asks matchmaking to put the player in a match, bots take all the other seats.
+func (pool *Pool) StartBotMatch(player *Player, rules string, difficulty string) {
+	if _, exists := rulesPresets[rules]; !exists {
+		rules = engine.DEFAULT_RULES
+	}
+
+	pool.botRequests <- botRequest{player, rules, difficulty}
+}
+
+// startRequestedBotMatch skips players who are gone or already got a match in the meantime.
+func (pool *Pool) startRequestedBotMatch(request botRequest) {
+	pool.mutex.Lock()
+	_, exists := pool.players[request.player.id]
+	playing := request.player.currentRoomId != uuid.Nil
+	if exists && !playing {
+		pool.unQueuePlayer(request.player)
+	}
+	pool.mutex.Unlock()
+
+	if !exists || playing {
+		return
+	}
+
+	pool.RemoveSpectator(request.player)
+	pool.startBotMatch([]*Player{request.player}, request.rules, request.difficulty)
+}
+
+// backfillQueue gives bots to the queued players who agreed to play them and have waited long enough.
+func (pool *Pool) backfillQueue(rules string) {
+	wait := time.Duration(rulesPresets[rules].BotBackfillTime * float64(time.Millisecond))
+	if wait <= 0 {
+		return
+	}
+
+	var waited []botRequest
+
+	pool.mutex.Lock()
+	for _, player := range slices.Clone(pool.queues[rules]) {
+		if player.botDifficulty == "" || time.Since(player.queuedAt) < wait {
+			continue
+		}
+
+		pool.unQueuePlayer(player)
+		waited = append(waited, botRequest{player, rules, player.botDifficulty})
+	}
+	pool.mutex.Unlock()
+
+	for _, request := range waited {
+		pool.startBotMatch([]*Player{request.player}, request.rules, request.difficulty)
+	}
+}
+
+func (pool *Pool) startBotMatch(players []*Player, rules string, difficulty string) {
+	preset := rulesPresets[rules]
+	for len(players) < 2*preset.TeamSize {
+		bot := NewBot(difficulty)
+		go bot.Run()
+
+		players = append(players, bot.player)
+	}
+
+	log.Println("Bot match " + rules + ": " + players[0].id.String())
+	pool.startRoom(pool.CreateRoom(players, preset))
+}
@@ -0,0 +1,108 @@
+package engine
+
+// Match is how far the match has got in the format the rules set.
+// Clock is the milliseconds left in the set and only runs with a time limit,
+// in overtime it stops and the next goal wins the set.
+type Match struct {
+	Set      uint
+	SetsA    uint
+	SetsB    uint
+	Clock    float64
+	Overtime bool
+	Over     bool
+	Winner   Side
+}
+
+func newMatch(rules GameRules) Match {
+	return Match{Set: 1, Clock: rules.TimeLimit}
+}
+
+// stepClock runs the set clock down and settles the set when it runs out,
+// a draw goes to overtime when the rules allow it.
+func (world *World) stepClock(dt float64, events []Event) []Event {
+	match := &world.Match
+	if world.Rules.TimeLimit == 0 || match.Overtime {
+		return events
+	}
+
+	match.Clock -= dt
+	if match.Clock > 0 {
+		return events
+	}
+
+	match.Clock = 0
+
+	switch {
+	case world.ScoreA > world.ScoreB:
+		return world.endSet(SIDE_A, events)
+	case world.ScoreB > world.ScoreA:
+		return world.endSet(SIDE_B, events)
+	case world.Rules.Overtime:
+		match.Overtime = true
+
+		return append(events, Event{Type: EVENT_OVERTIME})
+	default:
+		return world.endSet(SIDE_NONE, events)
+	}
+}
+
+// checkSet ends the set after a goal when the scorer has reached the goal limit with a big enough lead,
+// or scored the golden goal in overtime.
+func (world *World) checkSet(scorer Side, events []Event) ([]Event, bool) {
+	if world.Match.Overtime {
+		return world.endSet(scorer, events), true
+	}
+
+	if world.Rules.MaxGoals == 0 {
+		return events, false
+	}
+
+	score, other := world.ScoreA, world.ScoreB
+	if scorer == SIDE_B {
+		score, other = other, score
+	}
+
+	if score < world.Rules.MaxGoals || score < other+world.Rules.WinBy {
+		return events, false
+	}
+
+	return world.endSet(scorer, events), true
+}
+
+// endSet gives the set to the winner, SIDE_NONE for a drawn one, and either ends the match
+// or starts the next set from a faceoff with the score reset.
+func (world *World) endSet(winner Side, events []Event) []Event {
+	match := &world.Match
+	switch winner {
+	case SIDE_A:
+		match.SetsA++
+	case SIDE_B:
+		match.SetsB++
+	}
+
+	events = append(events, Event{Type: EVENT_SET_END, Side: winner})
+
+	needed := world.Rules.Sets/2 + 1
+	if match.SetsA >= needed || match.SetsB >= needed || match.Set == world.Rules.Sets {
+		match.Over = true
+		if match.SetsA > match.SetsB {
+			match.Winner = SIDE_A
+		} else if match.SetsB > match.SetsA {
+			match.Winner = SIDE_B
+		}
+
+		return append(events, Event{Type: EVENT_MATCH_END, Side: match.Winner})
+	}
+
+	match.Set++
+	match.Clock = world.Rules.TimeLimit
+	match.Overtime = false
+	world.ScoreA, world.ScoreB = 0, 0
+	world.LastServe = SIDE_NONE
+
+	for i := 0; i < world.PuckCount; i++ {
+		world.resetPuck(i, world.Rink.Faceoff)
+	}
+
+	return events
+}
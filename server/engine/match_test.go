@@ -0,0 +1,141 @@
+package engine
+
+import "testing"
+
+// scoreFor drops the puck into the goal the side attacks and steps the world once.
+func scoreFor(world World, side Side) (World, []Event) {
+	goal := world.Rink.GoalB
+	if side == SIDE_B {
+		goal = world.Rink.GoalA
+	}
+
+	world.Pucks[0] = Puck{Position: goal.Center()}
+
+	return Step(world, Inputs{}, world.Rules.PhysicsStep, nil)
+}
+
+// idle steps the world with nobody moving for the duration in milliseconds.
+func idle(world World, duration float64) (World, []Event) {
+	var events []Event
+	for elapsed := 0.0; elapsed < duration; elapsed += world.Rules.PhysicsStep {
+		world, events = Step(world, Inputs{}, world.Rules.PhysicsStep, events)
+	}
+
+	return world, events
+}
+
+func findEvent(events []Event, eventType EventType) (Event, bool) {
+	for _, event := range events {
+		if event.Type == eventType {
+			return event, true
+		}
+	}
+
+	return Event{}, false
+}
+
+func matchRules(t *testing.T, maxGoals uint, winBy uint, sets uint, timeLimit float64, overtime bool) (*Rink, GameRules) {
+	rules := DefaultRules()
+	rules.MaxGoals, rules.WinBy, rules.Sets = maxGoals, winBy, sets
+	rules.TimeLimit, rules.Overtime = timeLimit, overtime
+	rules.PossessionTime = 0
+
+	return testRink(t, rules.Rink), rules
+}
+
+func TestWinByTwo(t *testing.T) {
+	rink, rules := matchRules(t, 3, 2, 1, 0, false)
+	world := NewWorld(rink, rules)
+
+	var events []Event
+	for _, scorer := range []Side{SIDE_A, SIDE_B, SIDE_A, SIDE_B, SIDE_A} {
+		world, events = scoreFor(world, scorer)
+		if _, ended := findEvent(events, EVENT_SET_END); ended {
+			t.Fatalf("set ended at %d:%d", world.ScoreA, world.ScoreB)
+		}
+	}
+
+	world, events = scoreFor(world, SIDE_A)
+	end, ended := findEvent(events, EVENT_MATCH_END)
+	if !ended || end.Side != SIDE_A || !world.Match.Over || world.Match.Winner != SIDE_A {
+		t.Fatalf("match not won by A at %d:%d: %v", world.ScoreA, world.ScoreB, events)
+	}
+
+	if world.ScoreA != 4 || world.ScoreB != 2 {
+		t.Fatalf("final score %d:%d, want 4:2", world.ScoreA, world.ScoreB)
+	}
+}
+
+func TestBestOfSets(t *testing.T) {
+	rink, rules := matchRules(t, 2, 1, 3, 0, false)
+	world := NewWorld(rink, rules)
+
+	var events []Event
+	for i, scorer := range []Side{SIDE_A, SIDE_A, SIDE_B, SIDE_B} {
+		world, events = scoreFor(world, scorer)
+		if i%2 == 0 {
+			continue
+		}
+
+		end, ended := findEvent(events, EVENT_SET_END)
+		if !ended || end.Side != scorer {
+			t.Fatalf("set %d not won by %v: %v", i/2+1, scorer, events)
+		}
+
+		if world.ScoreA != 0 || world.ScoreB != 0 || world.Match.Set != uint(i/2+2) || world.Match.Over {
+			t.Fatalf("set %d not reset: %d:%d, set %d", i/2+1, world.ScoreA, world.ScoreB, world.Match.Set)
+		}
+	}
+
+	world, _ = scoreFor(world, SIDE_B)
+	world, events = scoreFor(world, SIDE_B)
+	if end, ended := findEvent(events, EVENT_MATCH_END); !ended || end.Side != SIDE_B {
+		t.Fatalf("match not won by B: %v", events)
+	}
+
+	if world.Match.SetsA != 1 || world.Match.SetsB != 2 {
+		t.Fatalf("sets %d:%d, want 1:2", world.Match.SetsA, world.Match.SetsB)
+	}
+}
+
+func TestTimedMatch(t *testing.T) {
+	rink, rules := matchRules(t, 0, 1, 1, 1000, false)
+	world := NewWorld(rink, rules)
+
+	world, _ = scoreFor(world, SIDE_B)
+	world, events := idle(world, 900)
+	if world.Match.Over {
+		t.Fatalf("match over before the time limit: %v", events)
+	}
+
+	world, events = idle(world, 200)
+	end, ended := findEvent(events, EVENT_MATCH_END)
+	if !ended || end.Side != SIDE_B || world.Match.Clock != 0 {
+		t.Fatalf("match not won by B on time: %v", events)
+	}
+}
+
+func TestTimedDraw(t *testing.T) {
+	rink, rules := matchRules(t, 0, 1, 1, 1000, false)
+	world := NewWorld(rink, rules)
+
+	world, events := idle(world, 1100)
+	if end, ended := findEvent(events, EVENT_MATCH_END); !ended || end.Side != SIDE_NONE || world.Match.Winner != SIDE_NONE {
+		t.Fatalf("match not drawn: %v", events)
+	}
+}
+
+func TestGoldenGoalOvertime(t *testing.T) {
+	rink, rules := matchRules(t, 0, 1, 1, 1000, true)
+	world := NewWorld(rink, rules)
+
+	world, events := idle(world, 5000)
+	if _, overtime := findEvent(events, EVENT_OVERTIME); !overtime || !world.Match.Overtime || world.Match.Over {
+		t.Fatalf("no overtime after a drawn set: %v", events)
+	}
+
+	world, events = scoreFor(world, SIDE_A)
+	if end, ended := findEvent(events, EVENT_MATCH_END); !ended || end.Side != SIDE_A {
+		t.Fatalf("golden goal did not win the match: %v", events)
+	}
+}
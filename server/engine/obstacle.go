@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const OBSTACLE_BUMPER = "bumper"
+const OBSTACLE_SEGMENT = "segment"
+
+const MAX_OBSTACLE_RESTITUTION = 2
+
+// Obstacle is a bumper (Center, Radius) or a segment (Start, End) inside the play area.
+// A moving obstacle loops through the Path offsets at Speed, so where it is
+// only depends on the world time.
+type Obstacle struct {
+	Type        string
+	Center      Vector
+	Radius      float64
+	Start       Vector
+	End         Vector
+	Restitution float64
+	Path        []Vector
+	Speed       float64
+}
+
+type obstacleDefinition struct {
+	Type        string      `json:"type"`
+	Center      rinkPoint   `json:"center"`
+	Radius      float64     `json:"radius"`
+	From        rinkPoint   `json:"from"`
+	To          rinkPoint   `json:"to"`
+	Restitution *float64    `json:"restitution"`
+	Path        []rinkPoint `json:"path"`
+	Speed       float64     `json:"speed"`
+}
+
+func NewObstacle(definition obstacleDefinition) (Obstacle, error) {
+	obstacle := Obstacle{
+		Type:        definition.Type,
+		Center:      definition.Center.vector(),
+		Radius:      definition.Radius,
+		Start:       definition.From.vector(),
+		End:         definition.To.vector(),
+		Restitution: 1,
+		Speed:       definition.Speed,
+	}
+
+	switch definition.Type {
+	case OBSTACLE_BUMPER:
+		if definition.Radius <= 0 {
+			return obstacle, fmt.Errorf("bumper at %v has no radius", definition.Center)
+		}
+	case OBSTACLE_SEGMENT:
+		if DistanceBetweenPoints(obstacle.Start, obstacle.End) < EPSILON {
+			return obstacle, fmt.Errorf("obstacle segment at %v has no length", definition.From)
+		}
+	default:
+		return obstacle, fmt.Errorf("unknown obstacle %q", definition.Type)
+	}
+
+	if definition.Restitution != nil {
+		obstacle.Restitution = *definition.Restitution
+	}
+
+	if obstacle.Restitution < 0 || obstacle.Restitution > MAX_OBSTACLE_RESTITUTION {
+		return obstacle, fmt.Errorf("%s restitution must be in [0, %d]", definition.Type, MAX_OBSTACLE_RESTITUTION)
+	}
+
+	for _, point := range definition.Path {
+		obstacle.Path = append(obstacle.Path, point.vector())
+	}
+
+	if len(obstacle.Path) > 1 && obstacle.Speed <= 0 {
+		return obstacle, fmt.Errorf("%s with a path must have a positive speed", definition.Type)
+	}
+
+	return obstacle, nil
+}
+
+func (obstacle Obstacle) Moving() bool {
+	return len(obstacle.Path) > 1 && obstacle.Speed > 0
+}
+
+// Offset returns how far the obstacle is moved from where it is defined at the given time.
+func (obstacle Obstacle) Offset(time float64) Vector {
+	if len(obstacle.Path) == 0 {
+		return NewVector(0, 0)
+	}
+
+	if !obstacle.Moving() {
+		return obstacle.Path[0]
+	}
+
+	length := 0.0
+	for i := range obstacle.Path {
+		length += DistanceBetweenPoints(obstacle.Path[i], obstacle.Path[(i+1)%len(obstacle.Path)])
+	}
+
+	if length < EPSILON {
+		return obstacle.Path[0]
+	}
+
+	distance := math.Mod(obstacle.Speed*time, length)
+	for i := range obstacle.Path {
+		from, to := obstacle.Path[i], obstacle.Path[(i+1)%len(obstacle.Path)]
+		piece := DistanceBetweenPoints(from, to)
+		if distance <= piece && piece > EPSILON {
+			return LerpVector(from, to, distance/piece)
+		}
+
+		distance -= piece
+	}
+
+	return obstacle.Path[0]
+}
+
+// Moved returns the obstacle shifted by the offset.
+func (obstacle Obstacle) Moved(offset Vector) Obstacle {
+	obstacle.Center = SumVectors(obstacle.Center, offset)
+	obstacle.Start = SumVectors(obstacle.Start, offset)
+	obstacle.End = SumVectors(obstacle.End, offset)
+
+	return obstacle
+}
+
+// Sweep finds when a puck moving by puckMove touches the obstacle that moves by obstacleMove at the same time.
+func (obstacle Obstacle) Sweep(puck Vector, puckMove Vector, radius float64, obstacleMove Vector) (bool, float64, Vector) {
+	if obstacle.Type == OBSTACLE_BUMPER {
+		return SweepCircles(puck, puckMove, radius, obstacle.Center, obstacleMove, obstacle.Radius)
+	}
+
+	return SweepCircleSegment(puck, SubstractVectors(puckMove, obstacleMove), radius, obstacle.Start, obstacle.End)
+}
+
+// Closest returns the point of the obstacle closest to the target and the distance the puck has to keep from it.
+func (obstacle Obstacle) Closest(target Vector, radius float64) (Vector, float64) {
+	if obstacle.Type == OBSTACLE_BUMPER {
+		return obstacle.Center, radius + obstacle.Radius
+	}
+
+	return ClosestPointOnSegment(obstacle.Start, obstacle.End, target), radius
+}
+
+// ModeObstacles places obstacles defined by a game mode around the rink faceoff.
+func ModeObstacles(rink *Rink, obstacles []Obstacle) []Obstacle {
+	placed := make([]Obstacle, 0, len(obstacles))
+	for _, obstacle := range obstacles {
+		placed = append(placed, obstacle.Moved(rink.Faceoff))
+	}
+
+	return placed
+}
+
+func (rink *Rink) validateObstacle(obstacle Obstacle) error {
+	points := []Vector{obstacle.Center}
+	if obstacle.Type == OBSTACLE_SEGMENT {
+		points = []Vector{obstacle.Start, obstacle.End}
+	}
+
+	for _, point := range points {
+		if rink.inGoal(SumVectors(point, obstacle.Offset(0))) {
+			return errors.New(obstacle.Type + " is inside a goal")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package engine
+
+import "math"
+
+const EPSILON = 0.0000001
+
+type Vector struct {
+	X float64
+	Y float64
+}
+
+func NewVector(x, y float64) Vector {
+	return Vector{X: x, Y: y}
+}
+
+func NewVectorFromPoints(a Vector, b Vector) Vector {
+	return SubstractVectors(b, a)
+}
+
+func Sqr(x float64) float64 {
+	return x * x
+}
+
+func Clamp(val, min, max int) int {
+	if val < min {
+		return min
+	}
+
+	if val > max {
+		return max
+	}
+
+	return val
+}
+
+func VectorLength(a Vector) float64 {
+	return math.Sqrt(a.X*a.X + a.Y*a.Y)
+}
+
+func SetVectorLength(a Vector, l float64) Vector {
+	scale := l / VectorLength(a)
+	return NewVector(a.X*scale, a.Y*scale)
+}
+
+func MultiplyVectors(a Vector, b Vector) float64 {
+	return a.X*b.X + a.Y*b.Y
+}
+
+func MultiplyVectorNumber(a Vector, num float64) Vector {
+	return NewVector(a.X*num, a.Y*num)
+}
+
+func SumVectors(a Vector, b Vector) Vector {
+	return NewVector(a.X+b.X, a.Y+b.Y)
+}
+
+func SubstractVectors(a Vector, b Vector) Vector {
+	return NewVector(a.X-b.X, a.Y-b.Y)
+}
+
+func Lerp(a float64, b float64, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func LerpVector(a Vector, b Vector, t float64) Vector {
+	return NewVector(Lerp(a.X, b.X, t), Lerp(a.Y, b.Y, t))
+}
+
+func NormalizeVector(a Vector) Vector {
+	length := VectorLength(a)
+
+	return NewVector(a.X/length, a.Y/length)
+}
+
+func ResizeVector(a Vector, length float64) Vector {
+	oldLength := VectorLength(a)
+	coef := length / oldLength
+
+	return NewVector(a.X*coef, a.Y*coef)
+}
+
+func DegreeToRad(r float64) float64 {
+	return r * math.Pi / 180
+}
+
+func RadToDegree(d float64) float64 {
+	return d * 180 / math.Pi
+}
+
+func DistanceBetweenPoints(a Vector, b Vector) float64 {
+	return math.Sqrt(Sqr(a.X-b.X) + Sqr(a.Y-b.Y))
+}
+
+func PointOnLine(lineStart Vector, lineEnd Vector, distance float64) Vector {
+	lineLength := DistanceBetweenPoints(lineStart, lineEnd)
+	t := distance / lineLength
+
+	return LerpVector(lineStart, lineEnd, t)
+}
+
+func AngleBetweenLines(a1 Vector, b1 Vector, a2 Vector, b2 Vector) float64 {
+	op1 := NewVector(b1.X-a1.X, b1.Y-a1.Y)
+	op2 := NewVector(b2.X-a2.X, b2.Y-a2.Y)
+
+	cos := MultiplyVectors(op1, op2) / (VectorLength(op1) * VectorLength(op2))
+	angle := RadToDegree(math.Acos(cos))
+	if angle > 90 {
+		angle = 180 - angle
+	}
+
+	return DegreeToRad(angle)
+}
+
+func LineFromPoints(lineStart Vector, lineEnd Vector) (float64, float64, float64) {
+	a := lineStart.Y - lineEnd.Y
+	b := lineEnd.X - lineStart.X
+	c := lineEnd.X*lineStart.Y - lineStart.X*lineEnd.Y
+
+	return a, b, -c
+}
+
+func PointLineDistance(point Vector, lineStart Vector, lineEnd Vector) float64 {
+	a, b, c := LineFromPoints(lineStart, lineEnd)
+
+	return math.Abs((a*point.X + b*point.Y + c)) / (math.Sqrt(Sqr(a) + Sqr(b)))
+}
+
+func PointBelongsSegment(lineStart, lineEnd, target Vector) bool {
+	return DistanceBetweenPoints(lineStart, target)+DistanceBetweenPoints(target, lineEnd)-DistanceBetweenPoints(lineStart, lineEnd) < EPSILON
+}
+
+func ClosestPoint(a Vector, b Vector, target Vector) Vector {
+	if DistanceBetweenPoints(a, target) < DistanceBetweenPoints(b, target) {
+		return a
+	} else {
+		return b
+	}
+}
+
+func ClosestPointOnSegment(lineStart Vector, lineEnd Vector, target Vector) Vector {
+	line := SubstractVectors(lineEnd, lineStart)
+	length := MultiplyVectors(line, line)
+	if length < EPSILON {
+		return lineStart
+	}
+
+	t := MultiplyVectors(SubstractVectors(target, lineStart), line) / length
+	t = math.Max(0, math.Min(1, t))
+
+	return LerpVector(lineStart, lineEnd, t)
+}
+
+func CheckLineCircleIntercection(lineStart Vector, lineEnd Vector, circlePos Vector, circleRadius float64) (bool, []Vector) {
+	if DistanceBetweenPoints(lineStart, lineEnd) < EPSILON {
+		return false, nil
+	}
+
+	a, b, c := LineFromPoints(lineStart, lineEnd)
+	c = -c
+
+	A := Sqr(a) + Sqr(b)
+	B := 2*a*b*circlePos.Y - 2*a*c - 2*Sqr(b)*circlePos.X
+	C := Sqr(b)*Sqr(circlePos.X) + Sqr(b)*Sqr(circlePos.Y) - 2*b*c*circlePos.Y + Sqr(c) - Sqr(b)*Sqr(circleRadius)
+
+	Discr := Sqr(B) - 4*A*C
+
+	if math.Abs(b) < EPSILON {
+		x1 := c / a
+
+		if math.Abs(circlePos.X-x1) > circleRadius {
+			return false, nil
+		}
+
+		if math.Abs((x1-circleRadius)-circlePos.X) < EPSILON || math.Abs((x1+circleRadius)-circlePos.X) < EPSILON {
+			return true, []Vector{NewVector(x1, circlePos.Y)}
+		}
+
+		dx := math.Abs(x1 - circlePos.X)
+		dy := math.Sqrt(Sqr(circleRadius) - Sqr(dx))
+
+		return true, []Vector{NewVector(x1, circlePos.Y+dy), NewVector(x1, circlePos.Y-dy)}
+	} else if math.Abs(Discr) < EPSILON {
+		x1 := -B / (2 * A)
+		y1 := (c - a*x1) / b
+
+		return true, []Vector{NewVector(x1, y1)}
+	} else if Discr < 0 {
+		return false, nil
+	} else {
+		Discr = math.Sqrt(Discr)
+
+		x1 := (-B + Discr) / (2 * A)
+		y1 := (c - a*x1) / b
+
+		x2 := (-B - Discr) / (2 * A)
+		y2 := (c - a*x2) / b
+
+		return true, []Vector{NewVector(x1, y1), NewVector(x1, y2)}
+	}
+}
+
+func CheckSegmentCircleIntercection(lineStart Vector, lineEnd Vector, circlePos Vector, circleRadius float64) (bool, []Vector) {
+	collision, hitPoints := CheckLineCircleIntercection(lineStart, lineEnd, circlePos, circleRadius)
+	if collision {
+		validHitPoints := make([]Vector, 0, 2)
+		for _, hitPoint := range hitPoints {
+			if PointBelongsSegment(lineStart, lineEnd, hitPoint) {
+				validHitPoints = append(validHitPoints, hitPoint)
+			}
+		}
+
+		if len(validHitPoints) > 0 {
+			return true, validHitPoints
+		}
+	}
+
+	return false, nil
+}
+
+func CheckLineLineIntercection(a Vector, b Vector, c Vector, d Vector) (bool, Vector) {
+	if DistanceBetweenPoints(a, b) < EPSILON || DistanceBetweenPoints(c, d) < EPSILON {
+		return false, Vector{}
+	}
+
+	denom := ((d.Y-c.Y)*(b.X-a.X) - (d.X-c.X)*(b.Y-a.Y))
+
+	if denom == 0 {
+		return false, Vector{}
+	}
+
+	ua := ((d.X-c.X)*(a.Y-c.Y) - (d.Y-c.Y)*(a.X-c.X)) / denom
+
+	return true, NewVector(a.X+ua*(b.X-a.X), a.Y+ua*(b.Y-a.Y))
+}
+
+func CheckSegmentSegmentIntercection(a Vector, b Vector, c Vector, d Vector) (bool, Vector) {
+	if DistanceBetweenPoints(a, b) < EPSILON || DistanceBetweenPoints(c, d) < EPSILON {
+		return false, Vector{}
+	}
+
+	line1 := NewVector(b.X-a.X, b.Y-a.Y)
+	line2 := NewVector(d.X-c.X, d.Y-c.Y)
+
+	denom := line1.X*line2.Y - line2.X*line1.Y
+
+	if denom == 0 {
+		return false, Vector{}
+	}
+
+	denomPositive := denom > 0
+
+	ua := a.X - c.X
+	ub := a.Y - c.Y
+
+	sn := line1.X*ub - line1.Y*ua
+
+	if (sn < 0) == denomPositive {
+		return false, Vector{}
+	}
+
+	tn := line2.X*ub - line2.Y*ua
+	if (tn < 0) == denomPositive {
+		return false, Vector{}
+	}
+
+	if sn > denom == denomPositive || tn > denom == denomPositive {
+		return false, Vector{}
+	}
+
+	t := tn / denom
+
+	return true, NewVector(a.X+(t*line1.X), a.Y+(t*line1.Y))
+}
+
+func SweepCircles(centerA Vector, moveA Vector, radiusA float64, centerB Vector, moveB Vector, radiusB float64) (bool, float64, Vector) {
+	relative := SubstractVectors(centerA, centerB)
+	move := SubstractVectors(moveA, moveB)
+	radius := radiusA + radiusB
+
+	a := MultiplyVectors(move, move)
+	b := 2 * MultiplyVectors(relative, move)
+	c := MultiplyVectors(relative, relative) - Sqr(radius)
+
+	if c <= 0 {
+		if b >= 0 || VectorLength(relative) < EPSILON {
+			return false, 0, Vector{}
+		}
+
+		return true, 0, NormalizeVector(relative)
+	}
+
+	if a < EPSILON || b >= 0 {
+		return false, 0, Vector{}
+	}
+
+	discr := Sqr(b) - 4*a*c
+	if discr < 0 {
+		return false, 0, Vector{}
+	}
+
+	t := (-b - math.Sqrt(discr)) / (2 * a)
+	if t < 0 || t > 1 {
+		return false, 0, Vector{}
+	}
+
+	return true, t, NormalizeVector(SumVectors(relative, MultiplyVectorNumber(move, t)))
+}
+
+func SweepCircleSegment(center Vector, move Vector, radius float64, lineStart Vector, lineEnd Vector) (bool, float64, Vector) {
+	line := SubstractVectors(lineEnd, lineStart)
+	lineLength := VectorLength(line)
+	if lineLength < EPSILON {
+		return SweepCircles(center, move, radius, lineStart, NewVector(0, 0), 0)
+	}
+
+	direction := MultiplyVectorNumber(line, 1/lineLength)
+	normal := NewVector(-direction.Y, direction.X)
+
+	distance := MultiplyVectors(SubstractVectors(center, lineStart), normal)
+	if distance < 0 {
+		normal = MultiplyVectorNumber(normal, -1)
+		distance = -distance
+	}
+
+	hit, toi, hitNormal := false, 0.0, Vector{}
+
+	approach := MultiplyVectors(move, normal)
+	if approach < 0 {
+		t := (distance - radius) / -approach
+		if distance < radius {
+			t = 0
+		}
+
+		if t <= 1 {
+			contact := SumVectors(center, MultiplyVectorNumber(move, t))
+			projection := MultiplyVectors(SubstractVectors(contact, lineStart), direction)
+
+			if projection >= 0 && projection <= lineLength {
+				hit, toi, hitNormal = true, t, normal
+			}
+		}
+	}
+
+	for _, point := range []Vector{lineStart, lineEnd} {
+		pointHit, t, pointNormal := SweepCircles(center, move, radius, point, NewVector(0, 0), 0)
+		if pointHit && (!hit || t < toi) {
+			hit, toi, hitNormal = true, t, pointNormal
+		}
+	}
+
+	return hit, toi, hitNormal
+}
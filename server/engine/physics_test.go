@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+const TEST_PRECISION = 1e-9
+
+func sameVector(a Vector, b Vector) bool {
+	return math.Abs(a.X-b.X) < TEST_PRECISION && math.Abs(a.Y-b.Y) < TEST_PRECISION
+}
+
+func TestSweepCircles(t *testing.T) {
+	cases := []struct {
+		name    string
+		centerA Vector
+		moveA   Vector
+		centerB Vector
+		moveB   Vector
+		hit     bool
+		toi     float64
+		normal  Vector
+	}{
+		{"head-on", NewVector(0, 0), NewVector(100, 0), NewVector(50, 0), NewVector(0, 0), true, 0.3, NewVector(-1, 0)},
+		{"both moving", NewVector(0, 0), NewVector(50, 0), NewVector(100, 0), NewVector(-50, 0), true, 0.8, NewVector(-1, 0)},
+		{"grazing", NewVector(0, 0), NewVector(100, 0), NewVector(50, 20), NewVector(0, 0), true, 0.5, NewVector(0, -1)},
+		{"near miss", NewVector(0, 0), NewVector(100, 0), NewVector(50, 21), NewVector(0, 0), false, 0, Vector{}},
+		{"overlapping and closing", NewVector(0, 0), NewVector(10, 0), NewVector(15, 0), NewVector(0, 0), true, 0, NewVector(-1, 0)},
+		{"overlapping and parting", NewVector(0, 0), NewVector(-10, 0), NewVector(15, 0), NewVector(0, 0), false, 0, Vector{}},
+		{"parallel", NewVector(0, 0), NewVector(100, 0), NewVector(50, 0), NewVector(100, 0), false, 0, Vector{}},
+		{"too far", NewVector(0, 0), NewVector(100, 0), NewVector(200, 0), NewVector(0, 0), false, 0, Vector{}},
+	}
+
+	for _, test := range cases {
+		hit, toi, normal := SweepCircles(test.centerA, test.moveA, 10, test.centerB, test.moveB, 10)
+		if hit != test.hit {
+			t.Errorf("%s: hit %v, want %v", test.name, hit, test.hit)
+
+			continue
+		}
+
+		if hit && (math.Abs(toi-test.toi) > TEST_PRECISION || !sameVector(normal, test.normal)) {
+			t.Errorf("%s: hit at %v with normal %v, want %v with %v", test.name, toi, normal, test.toi, test.normal)
+		}
+	}
+}
+
+func TestSweepCircleSegment(t *testing.T) {
+	lineStart, lineEnd := NewVector(0, 100), NewVector(200, 100)
+
+	cases := []struct {
+		name   string
+		center Vector
+		move   Vector
+		hit    bool
+		toi    float64
+		normal Vector
+	}{
+		{"head-on", NewVector(100, 0), NewVector(0, 200), true, 0.45, NewVector(0, -1)},
+		{"head-on from the other side", NewVector(100, 200), NewVector(0, -200), true, 0.45, NewVector(0, 1)},
+		{"grazing the end", NewVector(-10, 50), NewVector(0, 100), true, 0.5, NewVector(-1, 0)},
+		{"overlapping and closing", NewVector(100, 95), NewVector(0, 10), true, 0, NewVector(0, -1)},
+		{"overlapping and parting", NewVector(100, 95), NewVector(0, -10), false, 0, Vector{}},
+		{"parallel", NewVector(100, 50), NewVector(100, 0), false, 0, Vector{}},
+		{"too short", NewVector(100, 0), NewVector(0, 50), false, 0, Vector{}},
+		{"past the end", NewVector(300, 0), NewVector(0, 200), false, 0, Vector{}},
+	}
+
+	for _, test := range cases {
+		hit, toi, normal := SweepCircleSegment(test.center, test.move, 10, lineStart, lineEnd)
+		if hit != test.hit {
+			t.Errorf("%s: hit %v, want %v", test.name, hit, test.hit)
+
+			continue
+		}
+
+		if hit && (math.Abs(toi-test.toi) > TEST_PRECISION || !sameVector(normal, test.normal)) {
+			t.Errorf("%s: hit at %v with normal %v, want %v with %v", test.name, toi, normal, test.toi, test.normal)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package engine
+
+import "math"
+
+const POWERUP_GROW = "grow"
+const POWERUP_SHRINK = "shrink"
+const POWERUP_FREEZE = "freeze"
+const POWERUP_BOOST = "boost"
+const POWERUP_GHOST = "ghost"
+
+var POWERUP_TYPES = []string{POWERUP_GROW, POWERUP_SHRINK, POWERUP_FREEZE, POWERUP_BOOST, POWERUP_GHOST}
+
+const MAX_POWERUPS = 3
+const MAX_EFFECTS = 10
+const POWERUP_SPAWN_ATTEMPTS = 10
+
+const POWERUP_GROW_SCALE = 1.5
+const POWERUP_SHRINK_SCALE = 0.5
+const POWERUP_BOOST_SCALE = 1.5
+
+// PowerUp lies on the rink for TimeLeft more milliseconds waiting to be touched.
+type PowerUp struct {
+	Type     string
+	Position Vector
+	TimeLeft float64
+}
+
+// Effect is a power-up collected by Side, it wears off in TimeLeft milliseconds.
+type Effect struct {
+	Type     string
+	Side     Side
+	TimeLeft float64
+}
+
+// Modifiers are what the effects currently change for one side.
+// GoalScale is the part of the side's own goal that is still open.
+type Modifiers struct {
+	MalletRadius int
+	GoalScale    float64
+	Frozen       bool
+	Boost        float64
+}
+
+func NewModifiers(rules GameRules) Modifiers {
+	return Modifiers{
+		MalletRadius: rules.MalletRadius,
+		GoalScale:    1,
+		Boost:        1,
+	}
+}
+
+func (world *World) Modifiers(side Side) Modifiers {
+	if side == SIDE_B {
+		return world.ModifiersB
+	}
+
+	return world.ModifiersA
+}
+
+func (world *World) ActivePowerUps() []PowerUp {
+	return world.PowerUps[:world.PowerUpCount]
+}
+
+func (world *World) ActiveEffects() []Effect {
+	return world.Effects[:world.EffectCount]
+}
+
+// Seed sets where the power-up randomness starts, worlds with the same seed spawn the same power-ups.
+func (world *World) Seed(seed uint64) {
+	world.Random = seed
+}
+
+// random returns a number in [0, 1) using splitmix64, so the world stays a plain value.
+func (world *World) random() float64 {
+	world.Random += 0x9E3779B97F4A7C15
+	z := world.Random
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	z ^= z >> 31
+
+	return float64(z>>11) / (1 << 53)
+}
+
+// updateEffects wears the effects off and works out the modifiers they leave.
+func (world *World) updateEffects(dt float64) {
+	world.ModifiersA, world.ModifiersB = NewModifiers(world.Rules), NewModifiers(world.Rules)
+
+	count := 0
+	for i := 0; i < world.EffectCount; i++ {
+		effect := world.Effects[i]
+		effect.TimeLeft -= dt
+		if effect.TimeLeft <= 0 {
+			continue
+		}
+
+		world.Effects[count] = effect
+		count++
+
+		own, opponent := &world.ModifiersA, &world.ModifiersB
+		if effect.Side == SIDE_B {
+			own, opponent = opponent, own
+		}
+
+		switch effect.Type {
+		case POWERUP_GROW:
+			own.MalletRadius = int(math.Round(float64(world.Rules.MalletRadius) * POWERUP_GROW_SCALE))
+		case POWERUP_SHRINK:
+			opponent.GoalScale = POWERUP_SHRINK_SCALE
+		case POWERUP_FREEZE:
+			opponent.Frozen = true
+		case POWERUP_BOOST:
+			own.Boost = POWERUP_BOOST_SCALE
+		case POWERUP_GHOST:
+			// A ghost only hides the pucks from the opponent, clients draw it from the broadcast effects.
+		}
+	}
+
+	world.EffectCount = count
+}
+
+// stepPowerUps lets mallets and pucks pick up power-ups, removes the old ones and spawns new ones.
+// A puck collects for the side that last hit it.
+func (world *World) stepPowerUps(dt float64, events []Event) []Event {
+	if len(world.Rules.PowerUps) == 0 {
+		return events
+	}
+
+	radius := float64(world.Rules.PowerUpRadius)
+	count := 0
+
+	for i := 0; i < world.PowerUpCount; i++ {
+		powerUp := world.PowerUps[i]
+		powerUp.TimeLeft -= dt
+
+		collector := SIDE_NONE
+		for _, mallet := range world.ActiveMallets() {
+			if DistanceBetweenPoints(powerUp.Position, mallet.Position) <= float64(world.Modifiers(mallet.Side).MalletRadius)+radius {
+				collector = mallet.Side
+
+				break
+			}
+		}
+
+		if collector == SIDE_NONE {
+			for _, puck := range world.ActivePucks() {
+				if puck.LastHit != SIDE_NONE && DistanceBetweenPoints(powerUp.Position, puck.Position) <= float64(world.Rules.PuckRadius)+radius {
+					collector = puck.LastHit
+
+					break
+				}
+			}
+		}
+
+		if collector != SIDE_NONE {
+			world.applyPowerUp(powerUp.Type, collector)
+			events = append(events, Event{Type: EVENT_POWERUP, Side: collector, PowerUp: powerUp.Type})
+
+			continue
+		}
+
+		if powerUp.TimeLeft > 0 {
+			world.PowerUps[count] = powerUp
+			count++
+		}
+	}
+
+	world.PowerUpCount = count
+
+	world.NextPowerUp -= dt
+	if world.NextPowerUp <= 0 {
+		world.NextPowerUp = world.Rules.PowerUpInterval
+		world.spawnPowerUp()
+	}
+
+	return events
+}
+
+// applyPowerUp starts the effect for the side, or restarts it when the side already has it.
+func (world *World) applyPowerUp(powerUpType string, side Side) {
+	duration := world.Rules.PowerUpDuration
+	if powerUpType == POWERUP_FREEZE {
+		duration = world.Rules.FreezeTime
+	}
+
+	for i := 0; i < world.EffectCount; i++ {
+		if world.Effects[i].Type == powerUpType && world.Effects[i].Side == side {
+			world.Effects[i].TimeLeft = duration
+
+			return
+		}
+	}
+
+	if world.EffectCount < MAX_EFFECTS {
+		world.Effects[world.EffectCount] = Effect{Type: powerUpType, Side: side, TimeLeft: duration}
+		world.EffectCount++
+	}
+}
+
+// spawnPowerUp puts a random power-up in a random free spot of a mallet zone, it gives up quietly when the rink is crowded.
+func (world *World) spawnPowerUp() {
+	if world.PowerUpCount == MAX_POWERUPS {
+		return
+	}
+
+	radius := float64(world.Rules.PowerUpRadius)
+	powerUpType := world.Rules.PowerUps[int(world.random()*float64(len(world.Rules.PowerUps)))]
+
+	for i := 0; i < POWERUP_SPAWN_ATTEMPTS; i++ {
+		zone := world.Rink.MalletA
+		if world.random() < 0.5 {
+			zone = world.Rink.MalletB
+		}
+
+		point := NewVector(
+			Lerp(zone.Min.X+radius, zone.Max.X-radius, world.random()),
+			Lerp(zone.Min.Y+radius, zone.Max.Y-radius, world.random()),
+		)
+
+		if world.spawnIsFree(point, radius) {
+			world.PowerUps[world.PowerUpCount] = PowerUp{Type: powerUpType, Position: point, TimeLeft: world.Rules.PowerUpLifetime}
+			world.PowerUpCount++
+
+			return
+		}
+	}
+}
+
+func (world *World) spawnIsFree(point Vector, radius float64) bool {
+	for _, wall := range world.Rink.Walls {
+		if DistanceBetweenPoints(point, ClosestPointOnSegment(wall.Start, wall.End, point)) < radius {
+			return false
+		}
+	}
+
+	for _, post := range world.Rink.Posts {
+		if DistanceBetweenPoints(point, post.Center) < radius+post.Radius {
+			return false
+		}
+	}
+
+	for _, obstacle := range world.Obstacles {
+		closest, distance := obstacle.Moved(obstacle.Offset(world.Time)).Closest(point, radius)
+		if DistanceBetweenPoints(point, closest) < distance {
+			return false
+		}
+	}
+
+	for _, puck := range world.ActivePucks() {
+		if DistanceBetweenPoints(point, puck.Position) < radius+float64(world.Rules.PuckRadius) {
+			return false
+		}
+	}
+
+	for _, mallet := range world.ActiveMallets() {
+		if DistanceBetweenPoints(point, mallet.Position) < radius+float64(world.Modifiers(mallet.Side).MalletRadius) {
+			return false
+		}
+	}
+
+	for _, powerUp := range world.ActivePowerUps() {
+		if DistanceBetweenPoints(point, powerUp.Position) < 2*radius {
+			return false
+		}
+	}
+
+	return true
+}
+
+// goalShields returns the walls that close the outer parts of the shrunken goals.
+func (world *World) goalShields() ([4]Wall, int) {
+	var shields [4]Wall
+	count := 0
+
+	for _, goal := range [2]struct {
+		zone  Zone
+		scale float64
+	}{{world.Rink.GoalA, world.ModifiersA.GoalScale}, {world.Rink.GoalB, world.ModifiersB.GoalScale}} {
+		if goal.scale >= 1 {
+			continue
+		}
+
+		mouth := world.Rink.GoalMouth(goal.zone)
+		cut := (1 - goal.scale) / 2
+		shields[count] = Wall{Start: mouth.Start, End: LerpVector(mouth.Start, mouth.End, cut)}
+		shields[count+1] = Wall{Start: LerpVector(mouth.Start, mouth.End, 1-cut), End: mouth.End}
+		count += 2
+	}
+
+	return shields, count
+}
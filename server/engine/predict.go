@@ -0,0 +1,35 @@
+package engine
+
+const MAX_PREDICTED_BOUNCES = 4
+
+// PredictPath is where a puck goes in the next duration milliseconds if nothing but the walls gets in its way.
+// The path starts at the puck and has a point for every bounce, speed is kept through them
+// and the path ends after MAX_PREDICTED_BOUNCES of them.
+func PredictPath(walls []Wall, radius float64, position Vector, magnitude Vector, duration float64) []Vector {
+	path := make([]Vector, 1, MAX_PREDICTED_BOUNCES+2)
+	path[0] = position
+
+	remaining := duration
+	for bounce := 0; bounce <= MAX_PREDICTED_BOUNCES && remaining > EPSILON; bounce++ {
+		move := MultiplyVectorNumber(magnitude, remaining)
+
+		hit, toi, normal := false, 1.0, Vector{}
+		for _, wall := range walls {
+			if collision, t, wallNormal := SweepCircleSegment(position, move, radius, wall.Start, wall.End); collision && t < toi {
+				hit, toi, normal = true, t, wallNormal
+			}
+		}
+
+		position = SumVectors(position, MultiplyVectorNumber(move, toi))
+		path = append(path, position)
+		if !hit {
+			break
+		}
+
+		remaining *= 1 - toi
+		magnitude = SubstractVectors(magnitude, MultiplyVectorNumber(normal, 2*MultiplyVectors(magnitude, normal)))
+		position = SumVectors(position, MultiplyVectorNumber(normal, COLLISION_DISTANCE))
+	}
+
+	return path
+}
@@ -0,0 +1,183 @@
+package engine
+
+import "math"
+
+// Serve holds the puck on Side's serve spot until that side hits it or TimeLeft runs out.
+type Serve struct {
+	Side     Side
+	TimeLeft float64
+}
+
+// PossessionLeft returns the side that has to play a puck soonest and how many milliseconds it has left,
+// a held serve counts as possession of the serving side.
+func (world *World) PossessionLeft() (Side, float64) {
+	side, left := SIDE_NONE, 0.0
+
+	for _, puck := range world.ActivePucks() {
+		holder, time := puck.Serve.Side, puck.Serve.TimeLeft
+		if holder == SIDE_NONE && world.Rules.PossessionTime > 0 {
+			holder, time = puck.Holder, math.Max(0, world.Rules.PossessionTime-puck.HoldTime)
+		}
+
+		if holder != SIDE_NONE && (side == SIDE_NONE || time < left) {
+			side, left = holder, time
+		}
+	}
+
+	return side, left
+}
+
+// checkPossession calls a foul on the side that kept the puck in its half for too long
+// and gives the serve to the other side.
+func (world *World) checkPossession(index int, dt float64, events []Event) []Event {
+	puck := &world.Pucks[index]
+	if puck.Serve.Side != SIDE_NONE {
+		puck.Holder, puck.HoldTime = SIDE_NONE, 0
+
+		return events
+	}
+
+	side := world.Rink.SideOf(puck.Position)
+	if side != puck.Holder {
+		puck.Holder, puck.HoldTime = side, 0
+	}
+
+	puck.HoldTime += dt
+	if puck.HoldTime < world.Rules.PossessionTime {
+		return events
+	}
+
+	events = append(events, Event{Type: EVENT_FOUL, Side: side, Puck: index})
+
+	return world.serve(index, side.Opponent(), events)
+}
+
+func hitBy(events []Event, side Side) bool {
+	for _, event := range events {
+		if event.Type == EVENT_MALLET_HIT && event.Side == side {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restart puts the puck back in play as the restart policy says,
+// receiver is the side that gets the serve when the conceding player serves.
+func (world *World) restart(index int, receiver Side, events []Event) []Event {
+	switch world.Rules.RestartPolicy {
+	case RESTART_CONCEDER:
+	case RESTART_ALTERNATE:
+		if world.LastServe != SIDE_NONE {
+			receiver = world.LastServe.Opponent()
+		}
+	default:
+		world.resetPuck(index, world.Rink.Faceoff)
+
+		return events
+	}
+
+	return world.serve(index, receiver, events)
+}
+
+func (world *World) serve(index int, side Side, events []Event) []Event {
+	world.resetPuck(index, world.Rink.ServeSpot(side))
+	world.Pucks[index].Serve = Serve{Side: side, TimeLeft: world.Rules.ServeTime}
+	world.LastServe = side
+
+	return append(events, Event{Type: EVENT_SERVE, Side: side, Puck: index})
+}
+
+// releaseServe sends the puck towards the opponent when the server took too long.
+func (world *World) releaseServe(index int) {
+	puck := &world.Pucks[index]
+
+	direction := SubstractVectors(world.Rink.ServeSpot(puck.Serve.Side.Opponent()), puck.Position)
+	if VectorLength(direction) > EPSILON {
+		puck.Magnitude = ResizeVector(direction, world.Rules.ServeReleaseSpeed)
+	}
+
+	puck.Serve = Serve{}
+}
+
+// resetPuck puts the puck at rest on the spot, or next to it along the rink width
+// when another puck is already there.
+func (world *World) resetPuck(index int, spot Vector) {
+	radius := float64(world.Rules.PuckRadius)
+	gap := 2*radius + 2*COLLISION_DISTANCE
+	position := spot
+
+	for i := 0; i <= 2*MAX_PUCKS; i++ {
+		offset := float64((i+1)/2) * gap
+		if i%2 == 1 {
+			offset = -offset
+		}
+
+		candidate := NewVector(spot.X+offset, spot.Y)
+		if candidate.X < radius || candidate.X > float64(world.Rink.Width)-radius {
+			continue
+		}
+
+		if world.spotIsFree(index, candidate) {
+			position = candidate
+
+			break
+		}
+	}
+
+	world.Pucks[index] = Puck{Position: position, Magnitude: NewVector(0, 0)}
+}
+
+func (world *World) spotIsFree(index int, spot Vector) bool {
+	for j := 0; j < world.PuckCount; j++ {
+		if j != index && DistanceBetweenPoints(world.Pucks[j].Position, spot) < float64(2*world.Rules.PuckRadius) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// freePuck resolves a stuck puck the way the rules say
+// and reports the side whose half it was stuck in.
+func (world *World) freePuck(index int, events []Event) []Event {
+	puck := &world.Pucks[index]
+	holder := world.Rink.SideOf(puck.Position)
+	puck.StuckTime = 0
+	events = append(events, Event{Type: EVENT_STUCK_PUCK, Side: holder, Puck: index})
+
+	// A puck that somehow got out of the rink cannot be nudged back in.
+	rule := world.Rules.StuckRule
+	if rule == STUCK_NUDGE && !world.Rink.Contains(puck.Position) {
+		rule = STUCK_AWARD
+	}
+
+	switch rule {
+	case STUCK_NUDGE:
+		direction := SubstractVectors(world.Rink.ServeSpot(holder.Opponent()), puck.Position)
+
+		// A puck pinned by a mallet slides out along it instead of being pushed back into it.
+		mallet := world.closestMallet(holder, puck.Position)
+
+		away := SubstractVectors(puck.Position, mallet)
+		if VectorLength(away) <= float64(world.Rules.PuckRadius+world.Modifiers(holder).MalletRadius)+2*COLLISION_DISTANCE {
+			tangent := NewVector(-away.Y, away.X)
+			if MultiplyVectors(tangent, direction) < 0 {
+				tangent = MultiplyVectorNumber(tangent, -1)
+			}
+
+			direction = tangent
+		}
+
+		if VectorLength(direction) > EPSILON {
+			puck.Magnitude = ResizeVector(direction, world.Rules.StuckNudgeSpeed)
+		}
+	case STUCK_AWARD:
+		world.resetPuck(index, world.Rink.ServeSpot(holder.Opponent()))
+	default:
+		// The side that did not pin the puck serves it whatever the restart policy after a goal.
+		return world.serve(index, holder.Opponent(), events)
+	}
+
+	return events
+}
@@ -0,0 +1,176 @@
+package engine
+
+import "testing"
+
+func possessionWorld(t *testing.T) World {
+	rules, err := RulesPreset(RULES_RANKED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A puck at rest would be freed as stuck long before its holder runs out of time.
+	rules.StuckTime = 10 * rules.PossessionTime
+
+	world := NewWorld(testRink(t, rules.Rink), rules)
+	world.Pucks[0] = Puck{Position: world.Rink.ServeSpot(SIDE_B)}
+
+	return world
+}
+
+func TestPossessionFoul(t *testing.T) {
+	world := possessionWorld(t)
+	if world.Rink.SideOf(world.Pucks[0].Position) != SIDE_B {
+		t.Fatal("puck is not in side B's half")
+	}
+
+	world, events := idle(world, world.Rules.PossessionTime-100)
+	if _, fouled := findEvent(events, EVENT_FOUL); fouled {
+		t.Fatalf("foul before the possession time ran out: %v", events)
+	}
+
+	if side, left := world.PossessionLeft(); side != SIDE_B || left > 100 {
+		t.Fatalf("possession %v with %v left, want B with under 100", side, left)
+	}
+
+	world, events = idle(world, 200)
+	foul, fouled := findEvent(events, EVENT_FOUL)
+	if !fouled || foul.Side != SIDE_B {
+		t.Fatalf("no foul on B: %v", events)
+	}
+
+	serve, served := findEvent(events, EVENT_SERVE)
+	if !served || serve.Side != SIDE_A || world.Pucks[0].Serve.Side != SIDE_A {
+		t.Fatalf("serve not handed to A: %v", events)
+	}
+
+	if !sameVector(world.Pucks[0].Position, world.Rink.ServeSpot(SIDE_A)) {
+		t.Fatalf("puck at %v, want A's serve spot %v", world.Pucks[0].Position, world.Rink.ServeSpot(SIDE_A))
+	}
+}
+
+func TestPossessionResetsInTheOtherHalf(t *testing.T) {
+	world := possessionWorld(t)
+
+	world, _ = idle(world, world.Rules.PossessionTime-100)
+	world.Pucks[0].Position = world.Rink.ServeSpot(SIDE_A)
+
+	world, events := idle(world, 200)
+	if _, fouled := findEvent(events, EVENT_FOUL); fouled {
+		t.Fatalf("foul after the puck changed halves: %v", events)
+	}
+
+	if side, left := world.PossessionLeft(); side != SIDE_A || left < world.Rules.PossessionTime-300 {
+		t.Fatalf("possession %v with %v left, want A's clock restarted", side, left)
+	}
+}
+
+// stuckWorld has a mallet of side A head for the target every step until the puck is freed.
+func stuckWorld(t *testing.T, rink *Rink, puck Vector, mallet Vector, target Position) (World, []Event) {
+	rules, err := RulesPreset(RULES_RANKED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules.PossessionTime = 0
+
+	world := NewWorld(rink, rules)
+	world.Pucks[0] = Puck{Position: puck}
+	world.Mallets[0].Position = mallet
+
+	var events []Event
+	for elapsed := 0.0; elapsed < rules.StuckTime+500; elapsed += rules.PhysicsStep {
+		world, events = Step(world, Inputs{&target}, rules.PhysicsStep, events)
+		if stuck, found := findEvent(events, EVENT_STUCK_PUCK); found {
+			if elapsed < rules.StuckTime-rules.PhysicsStep {
+				t.Fatalf("puck freed after %v, before the stuck time", elapsed)
+			}
+
+			if stuck.Side != SIDE_A {
+				t.Fatalf("puck freed in the half of %v, want A", stuck.Side)
+			}
+
+			break
+		}
+	}
+
+	return world, events
+}
+
+func TestStuckPuck(t *testing.T) {
+	rink := testRink(t, DEFAULT_RINK)
+	withPost := *rink
+	withPost.Posts = []Post{{Center: NewVector(400, 800), Radius: 20}}
+
+	cases := []struct {
+		name   string
+		rink   *Rink
+		puck   Vector
+		mallet Vector
+		target Position
+	}{
+		{"pinned to the side wall", rink, NewVector(750, 900), NewVector(500, 900), NewPosition(800, 900)},
+		{"pinned to a post", &withPost, NewVector(400, 860), NewVector(400, 1000), NewPosition(400, 800)},
+		{"lying loose", rink, NewVector(100, 700), NewVector(600, 1100), NewPosition(600, 1100)},
+	}
+
+	for _, test := range cases {
+		world, events := stuckWorld(t, test.rink, test.puck, test.mallet, test.target)
+		if _, found := findEvent(events, EVENT_STUCK_PUCK); !found {
+			t.Errorf("%s: puck at %v never freed", test.name, world.Pucks[0].Position)
+
+			continue
+		}
+
+		if !sameVector(world.Pucks[0].Position, rink.ServeSpot(SIDE_B)) {
+			t.Errorf("%s: puck at %v, want B's serve spot %v", test.name, world.Pucks[0].Position, rink.ServeSpot(SIDE_B))
+		}
+	}
+}
+
+func TestDribbledPuckIsNotStuck(t *testing.T) {
+	rules, err := RulesPreset(RULES_RANKED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules.PossessionTime = 0
+
+	world := NewWorld(testRink(t, rules.Rink), rules)
+	world.Pucks[0] = Puck{Position: NewVector(400, 1000)}
+
+	var events []Event
+	for elapsed := 0.0; elapsed < 2*rules.StuckTime; elapsed += rules.PhysicsStep {
+		// The mallet rests against the puck from below and eases it along without a hit.
+		lean := SumVectors(world.Pucks[0].Position, NewVector(0, float64(rules.PuckRadius+rules.MalletRadius)-0.2))
+		world.Mallets[0].Position, world.Mallets[0].Target = lean, lean
+
+		world, events = Step(world, Inputs{}, rules.PhysicsStep, events)
+	}
+
+	if VectorLength(world.Pucks[0].Magnitude) >= rules.StuckSpeed {
+		t.Fatalf("puck moves at %v, not slow enough to be stuck", VectorLength(world.Pucks[0].Magnitude))
+	}
+
+	if stuck, found := findEvent(events, EVENT_STUCK_PUCK); found {
+		t.Fatalf("dribbled puck freed as stuck: %v", stuck)
+	}
+}
+
+func TestPuckOutsideTheRink(t *testing.T) {
+	for _, rule := range []string{STUCK_NUDGE, STUCK_AWARD, STUCK_SERVE} {
+		rules := DefaultRules()
+		rules.StuckRule = rule
+
+		world := NewWorld(testRink(t, rules.Rink), rules)
+		world.Pucks[0] = Puck{Position: NewVector(840, 900)}
+
+		world, events := Step(world, Inputs{}, rules.PhysicsStep, nil)
+		if _, found := findEvent(events, EVENT_STUCK_PUCK); !found {
+			t.Errorf("%s: puck outside the rink not freed: %v", rule, events)
+		}
+
+		if !world.Rink.Contains(world.Pucks[0].Position) {
+			t.Errorf("%s: puck left at %v", rule, world.Pucks[0].Position)
+		}
+	}
+}
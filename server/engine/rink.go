@@ -0,0 +1,394 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+)
+
+const DEFAULT_RINK = "classic"
+const RINK_TOLERANCE = 0.5
+const ARC_SEGMENT_ANGLE = 10.0
+
+const PIECE_SEGMENT = "segment"
+const PIECE_ARC = "arc"
+const PIECE_GAP = "gap"
+
+const SIDE_TOP = "top"
+const SIDE_BOTTOM = "bottom"
+
+type Wall struct {
+	Start Vector
+	End   Vector
+}
+
+type Post struct {
+	Center Vector
+	Radius float64
+}
+
+type Zone struct {
+	Min Vector
+	Max Vector
+}
+
+func (zone Zone) Contains(point Vector) bool {
+	return point.X >= zone.Min.X && point.X <= zone.Max.X && point.Y >= zone.Min.Y && point.Y <= zone.Max.Y
+}
+
+func (zone Zone) Center() Vector {
+	return LerpVector(zone.Min, zone.Max, 0.5)
+}
+
+func (zone Zone) Overlaps(other Zone) bool {
+	return zone.Min.X < other.Max.X && other.Min.X < zone.Max.X && zone.Min.Y < other.Max.Y && other.Min.Y < zone.Max.Y
+}
+
+func (zone Zone) Clamp(pos Position, radius int) Position {
+	pos.X = Clamp(pos.X, int(math.Ceil(zone.Min.X))+radius, int(math.Floor(zone.Max.X))-radius)
+	pos.Y = Clamp(pos.Y, int(math.Ceil(zone.Min.Y))+radius, int(math.Floor(zone.Max.Y))-radius)
+
+	return pos
+}
+
+func (zone Zone) ClampPoint(point Vector, radius float64) Vector {
+	return NewVector(
+		math.Max(zone.Min.X+radius, math.Min(zone.Max.X-radius, point.X)),
+		math.Max(zone.Min.Y+radius, math.Min(zone.Max.Y-radius, point.Y)),
+	)
+}
+
+type Rink struct {
+	Name      string
+	Width     int
+	Height    int
+	Faceoff   Vector
+	Outline   []Vector
+	Walls     []Wall
+	Posts     []Post
+	Obstacles []Obstacle
+	GoalA     Zone
+	GoalB     Zone
+	MalletA   Zone
+	MalletB   Zone
+}
+
+type rinkPoint [2]float64
+
+func (point rinkPoint) vector() Vector {
+	return NewVector(point[0], point[1])
+}
+
+type rinkPiece struct {
+	Type       string    `json:"type"`
+	From       rinkPoint `json:"from"`
+	To         rinkPoint `json:"to"`
+	Center     rinkPoint `json:"center"`
+	Radius     float64   `json:"radius"`
+	StartAngle float64   `json:"startAngle"`
+	EndAngle   float64   `json:"endAngle"`
+}
+
+type rinkCircle struct {
+	Center rinkPoint `json:"center"`
+	Radius float64   `json:"radius"`
+}
+
+type rinkZone struct {
+	Side string    `json:"side"`
+	Min  rinkPoint `json:"min"`
+	Max  rinkPoint `json:"max"`
+}
+
+type rinkDefinition struct {
+	Name      string               `json:"name"`
+	Width     int                  `json:"width"`
+	Height    int                  `json:"height"`
+	Faceoff   *rinkPoint           `json:"faceoff"`
+	Outline   []rinkPiece          `json:"outline"`
+	Posts     []rinkCircle         `json:"posts"`
+	Obstacles []obstacleDefinition `json:"obstacles"`
+	Goals     []rinkZone           `json:"goals"`
+	Mallets   []rinkZone           `json:"mallets"`
+}
+
+func LoadRinksFrom(fsys fs.FS, dir string, rinks map[string]*Rink) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		rink, err := ParseRink(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		rinks[rink.Name] = rink
+	}
+
+	return nil
+}
+
+func ParseRink(data []byte) (*Rink, error) {
+	var definition rinkDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, err
+	}
+
+	return NewRink(definition)
+}
+
+func NewRink(definition rinkDefinition) (*Rink, error) {
+	if strings.TrimSpace(definition.Name) == "" {
+		return nil, errors.New("rink name is empty")
+	}
+
+	if definition.Width <= 0 || definition.Height <= 0 {
+		return nil, errors.New("rink size must be positive")
+	}
+
+	rink := &Rink{
+		Name:    definition.Name,
+		Width:   definition.Width,
+		Height:  definition.Height,
+		Faceoff: NewVector(float64(definition.Width)/2, float64(definition.Height)/2),
+		Walls:   make([]Wall, 0, len(definition.Outline)),
+		Posts:   make([]Post, 0, len(definition.Posts)),
+	}
+
+	if definition.Faceoff != nil {
+		rink.Faceoff = definition.Faceoff.vector()
+	}
+
+	goals, err := buildZones("goal", definition.Goals)
+	if err != nil {
+		return nil, err
+	}
+
+	if goals[0].Overlaps(goals[1]) {
+		return nil, errors.New("goals overlap")
+	}
+
+	rink.GoalB, rink.GoalA = goals[0], goals[1]
+
+	mallets, err := buildZones("mallet zone", definition.Mallets)
+	if err != nil {
+		return nil, err
+	}
+
+	rink.MalletB, rink.MalletA = mallets[0], mallets[1]
+
+	if err := rink.buildOutline(definition.Outline); err != nil {
+		return nil, err
+	}
+
+	for _, post := range definition.Posts {
+		if post.Radius <= 0 {
+			return nil, fmt.Errorf("post at %v has no radius", post.Center)
+		}
+
+		rink.Posts = append(rink.Posts, Post{Center: post.Center.vector(), Radius: post.Radius})
+	}
+
+	for _, definition := range definition.Obstacles {
+		obstacle, err := NewObstacle(definition)
+		if err == nil {
+			err = rink.validateObstacle(obstacle)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		rink.Obstacles = append(rink.Obstacles, obstacle)
+	}
+
+	if rink.inGoal(rink.Faceoff) {
+		return nil, errors.New("faceoff point is inside a goal")
+	}
+
+	return rink, nil
+}
+
+func buildZones(kind string, definitions []rinkZone) ([2]Zone, error) {
+	var zones [2]Zone
+	found := [2]bool{}
+
+	for _, definition := range definitions {
+		var index int
+		switch definition.Side {
+		case SIDE_TOP:
+			index = 0
+		case SIDE_BOTTOM:
+			index = 1
+		default:
+			return zones, fmt.Errorf("%s has unknown side %q", kind, definition.Side)
+		}
+
+		if found[index] {
+			return zones, fmt.Errorf("%s for side %s is defined twice", kind, definition.Side)
+		}
+
+		if definition.Min[0] >= definition.Max[0] || definition.Min[1] >= definition.Max[1] {
+			return zones, fmt.Errorf("%s for side %s is empty", kind, definition.Side)
+		}
+
+		zones[index] = Zone{Min: definition.Min.vector(), Max: definition.Max.vector()}
+		found[index] = true
+	}
+
+	if !found[0] || !found[1] {
+		return zones, fmt.Errorf("%s must be defined for both sides", kind)
+	}
+
+	return zones, nil
+}
+
+func (rink *Rink) buildOutline(pieces []rinkPiece) error {
+	if len(pieces) == 0 {
+		return errors.New("outline is empty")
+	}
+
+	var first, last Vector
+	started := false
+
+	for _, piece := range pieces {
+		var points []Vector
+
+		switch piece.Type {
+		case PIECE_SEGMENT, PIECE_GAP:
+			points = []Vector{piece.From.vector(), piece.To.vector()}
+		case PIECE_ARC:
+			if piece.Radius <= 0 {
+				return fmt.Errorf("arc around %v has no radius", piece.Center)
+			}
+
+			points = arcPoints(piece.Center.vector(), piece.Radius, piece.StartAngle, piece.EndAngle)
+		default:
+			return fmt.Errorf("unknown outline piece %q", piece.Type)
+		}
+
+		start, end := points[0], points[len(points)-1]
+		if DistanceBetweenPoints(start, end) < EPSILON {
+			return fmt.Errorf("outline %s at %v has no length", piece.Type, start)
+		}
+
+		if started && DistanceBetweenPoints(last, start) > RINK_TOLERANCE {
+			return fmt.Errorf("outline is open between %v and %v", last, start)
+		}
+
+		if !started {
+			first, started = start, true
+		}
+
+		last = end
+		rink.Outline = append(rink.Outline, points[1:]...)
+
+		if piece.Type == PIECE_GAP {
+			if !rink.inGoal(start) || !rink.inGoal(end) {
+				return fmt.Errorf("outline gap from %v to %v is outside of goals", start, end)
+			}
+
+			continue
+		}
+
+		for i := 1; i < len(points); i++ {
+			rink.Walls = append(rink.Walls, Wall{Start: points[i-1], End: points[i]})
+		}
+	}
+
+	if DistanceBetweenPoints(last, first) > RINK_TOLERANCE {
+		return fmt.Errorf("outline is open between %v and %v", last, first)
+	}
+
+	return nil
+}
+
+// Contains reports whether the point is within the outline, goal mouths included.
+func (rink *Rink) Contains(point Vector) bool {
+	inside := false
+	for i, j := 0, len(rink.Outline)-1; i < len(rink.Outline); j, i = i, i+1 {
+		a, b := rink.Outline[i], rink.Outline[j]
+		if (a.Y > point.Y) != (b.Y > point.Y) && point.X < a.X+(point.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func (rink *Rink) inGoal(point Vector) bool {
+	return rink.GoalA.Contains(point) || rink.GoalB.Contains(point)
+}
+
+func arcPoints(center Vector, radius float64, startAngle float64, endAngle float64) []Vector {
+	count := int(math.Ceil(math.Abs(endAngle-startAngle) / ARC_SEGMENT_ANGLE))
+	if count < 1 {
+		count = 1
+	}
+
+	points := make([]Vector, 0, count+1)
+	for i := 0; i <= count; i++ {
+		angle := DegreeToRad(Lerp(startAngle, endAngle, float64(i)/float64(count)))
+		points = append(points, NewVector(center.X+radius*math.Cos(angle), center.Y+radius*math.Sin(angle)))
+	}
+
+	return points
+}
+
+// SideOf returns the side whose half of the rink the point is in.
+func (rink *Rink) SideOf(point Vector) Side {
+	if DistanceBetweenPoints(point, rink.MalletA.Center()) <= DistanceBetweenPoints(point, rink.MalletB.Center()) {
+		return SIDE_A
+	}
+
+	return SIDE_B
+}
+
+// ServeSpot is where the puck is put when it is given to the side.
+func (rink *Rink) ServeSpot(side Side) Vector {
+	if side == SIDE_B {
+		return rink.MalletB.Center()
+	}
+
+	return rink.MalletA.Center()
+}
+
+// GoalMouth is the edge of the goal that faces the faceoff, the one pucks cross to score.
+func (rink *Rink) GoalMouth(goal Zone) Wall {
+	switch {
+	case rink.Faceoff.Y > goal.Max.Y:
+		return Wall{Start: NewVector(goal.Min.X, goal.Max.Y), End: goal.Max}
+	case rink.Faceoff.Y < goal.Min.Y:
+		return Wall{Start: goal.Min, End: NewVector(goal.Max.X, goal.Min.Y)}
+	case rink.Faceoff.X > goal.Max.X:
+		return Wall{Start: NewVector(goal.Max.X, goal.Min.Y), End: goal.Max}
+	default:
+		return Wall{Start: goal.Min, End: NewVector(goal.Min.X, goal.Max.Y)}
+	}
+}
+
+func (rink *Rink) FlipPosition(pos Position) Position {
+	return Position{
+		X: rink.Width - pos.X,
+		Y: rink.Height - pos.Y,
+	}
+}
+
+func (rink *Rink) FlipVector(point Vector) Vector {
+	return NewVector(float64(rink.Width)-point.X, float64(rink.Height)-point.Y)
+}
+
+func (rink *Rink) FlipZone(zone Zone) Zone {
+	return Zone{Min: rink.FlipVector(zone.Max), Max: rink.FlipVector(zone.Min)}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseRink(t *testing.T) {
+	data, err := os.ReadFile("../rinks/classic.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		change func(definition *rinkDefinition)
+		err    string
+	}{
+		{"classic", func(definition *rinkDefinition) {}, ""},
+		{"open outline", func(definition *rinkDefinition) {
+			definition.Outline = definition.Outline[:len(definition.Outline)-1]
+		}, "outline is open"},
+		{"broken outline", func(definition *rinkDefinition) {
+			definition.Outline[5].To = rinkPoint{800, 1100}
+		}, "outline is open"},
+		{"overlapping goals", func(definition *rinkDefinition) {
+			definition.Goals[1].Min = rinkPoint{300, -100}
+		}, "goals overlap"},
+		{"gap outside of goals", func(definition *rinkDefinition) {
+			definition.Outline[0].Type = PIECE_GAP
+		}, "outside of goals"},
+		{"missing goal side", func(definition *rinkDefinition) {
+			definition.Goals = definition.Goals[:1]
+		}, "goal must be defined for both sides"},
+		{"missing mallet zone side", func(definition *rinkDefinition) {
+			definition.Mallets = definition.Mallets[1:]
+		}, "mallet zone must be defined for both sides"},
+	}
+
+	for _, test := range cases {
+		var definition rinkDefinition
+		if err := json.Unmarshal(data, &definition); err != nil {
+			t.Fatal(err)
+		}
+
+		test.change(&definition)
+
+		changed, err := json.Marshal(definition)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = ParseRink(changed)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestRinkContains(t *testing.T) {
+	rink := testRink(t, DEFAULT_RINK)
+
+	cases := []struct {
+		point  Vector
+		inside bool
+	}{
+		{NewVector(400, 600), true},
+		{NewVector(1, 1199), true},
+		{NewVector(400, -60), true},
+		{NewVector(400, 1260), true},
+		{NewVector(840, 900), false},
+		{NewVector(100, -20), false},
+		{NewVector(-1, 600), false},
+		{NewVector(400, 1300), false},
+	}
+
+	for _, test := range cases {
+		if inside := rink.Contains(test.point); inside != test.inside {
+			t.Errorf("point %v inside %v, want %v", test.point, inside, test.inside)
+		}
+	}
+}
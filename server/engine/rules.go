@@ -0,0 +1,398 @@
+package engine
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+)
+
+const DEFAULT_DRAG = 0.01
+const SPIN_DRAG = 0.02
+const MAX_PUCK_MAGNITUDE = 2
+const MAX_GOALS uint = 10
+const WIN_BY uint = 1
+const SETS uint = 1
+const PUCK_COUNT = 1
+const PHYSICS_STEP float64 = 5
+const PHYSICS_CYCLE = 20
+const NETWORK_CYCLE = 20
+const PLAYER_MESSAGE_THROTTLE = 20
+
+const PUCK_RADIUS int = 40
+const MALLET_RADIUS int = 40
+const PUCK_MASS = 1.0
+const MALLET_MASS = 4.0
+const MALLET_RESTITUTION = 0.9
+const PUCK_RESTITUTION = 0.9
+const WALL_RESTITUTION = 0.95
+const MALLET_FRICTION = 0.3
+const WALL_FRICTION = 0.15
+const MALLET_MAX_SPEED = 2.5
+const MALLET_ACCELERATION = 0.03
+
+const STUCK_SPEED = 0.05
+const STUCK_TIME = 3000
+const STUCK_NUDGE_SPEED = 0.8
+
+const STUCK_NUDGE = "nudge"
+const STUCK_AWARD = "award"
+const STUCK_SERVE = "serve"
+
+const SERVE_TIME = 7000
+const SERVE_RELEASE_SPEED = 0.8
+const POSSESSION_TIME = 7000
+
+const POWERUP_RADIUS int = 25
+const POWERUP_INTERVAL = 8000
+const POWERUP_LIFETIME = 10000
+const POWERUP_DURATION = 8000
+const FREEZE_TIME = 1500
+
+const COUNTDOWN_TIME = 3000
+const GOAL_FREEZE_TIME = 1500
+const RESUME_TIME = 1000
+
+const PAUSES_PER_PLAYER = 2
+const PAUSE_TIME = 60000
+const PAUSE_NETWORK_CYCLE = 500
+const RECONNECT_TIME = 30000
+const BOT_BACKFILL_TIME = 30000
+
+const TEAM_SIZE = 1
+const TEAM_ZONE_FREE = "free"
+const TEAM_ZONE_LANES = "lanes"
+
+const RESTART_FACEOFF = "faceoff"
+const RESTART_CONCEDER = "conceder"
+const RESTART_ALTERNATE = "alternate"
+
+const RULES_CASUAL = "casual"
+const RULES_RANKED = "ranked"
+const RULES_ARCADE = "arcade"
+const RULES_CRAZY = "crazy"
+const RULES_DOUBLES = "doubles"
+const DEFAULT_RULES = RULES_RANKED
+
+var RULES_PRESETS = []string{RULES_CASUAL, RULES_RANKED, RULES_ARCADE, RULES_CRAZY, RULES_DOUBLES}
+
+// Obstacles are added by the game mode on top of the rink ones, placed relative to the faceoff.
+// PowerUps are the power-up types that can spawn, none means power-ups are off.
+// A set is won at MaxGoals with a lead of WinBy, or by the leader when TimeLimit runs out,
+// 0 turns either off. The match is the best of Sets.
+// CountdownTime, GoalFreezeTime and ResumeTime are how long the room holds play before the match and after a goal.
+// Each player may pause the match PausesPerPlayer times for at most PauseTime,
+// meanwhile the world is sent every PauseNetworkCycle, as it is while waiting
+// up to ReconnectTime for a dropped player, 0 forfeits at once.
+// A queued player who agreed to play bots gets one after BotBackfillTime, 0 never backfills.
+// Each team has TeamSize mallets, TeamZone says whether teammates share their half or each keep to a lane of it.
+type GameRules struct {
+	Name                  string
+	Rink                  string
+	Obstacles             []Obstacle
+	Drag                  float64
+	SpinDrag              float64
+	MaxPuckMagnitude      float64
+	MaxGoals              uint
+	WinBy                 uint
+	Sets                  uint
+	TimeLimit             float64
+	Overtime              bool
+	PuckCount             int
+	PhysicsStep           float64
+	PhysicsCycle          int
+	NetworkCycle          int
+	PlayerMessageThrottle int
+	PuckRadius            int
+	MalletRadius          int
+	PuckMass              float64
+	MalletMass            float64
+	MalletRestitution     float64
+	PuckRestitution       float64
+	WallRestitution       float64
+	MalletFriction        float64
+	WallFriction          float64
+	MalletMaxSpeed        float64
+	MalletAcceleration    float64
+	StuckSpeed            float64
+	StuckTime             float64
+	StuckRule             string
+	StuckNudgeSpeed       float64
+	RestartPolicy         string
+	ServeTime             float64
+	ServeReleaseSpeed     float64
+	PossessionTime        float64
+	PowerUps              []string
+	PowerUpRadius         int
+	PowerUpInterval       float64
+	PowerUpLifetime       float64
+	PowerUpDuration       float64
+	FreezeTime            float64
+	CountdownTime         float64
+	GoalFreezeTime        float64
+	ResumeTime            float64
+	PausesPerPlayer       int
+	PauseTime             float64
+	PauseNetworkCycle     int
+	ReconnectTime         float64
+	BotBackfillTime       float64
+	TeamSize              int
+	TeamZone              string
+}
+
+func DefaultRules() GameRules {
+	return GameRules{
+		Name:                  DEFAULT_RULES,
+		Rink:                  DEFAULT_RINK,
+		Drag:                  DEFAULT_DRAG,
+		SpinDrag:              SPIN_DRAG,
+		MaxPuckMagnitude:      MAX_PUCK_MAGNITUDE,
+		MaxGoals:              MAX_GOALS,
+		WinBy:                 WIN_BY,
+		Sets:                  SETS,
+		PuckCount:             PUCK_COUNT,
+		PhysicsStep:           PHYSICS_STEP,
+		PhysicsCycle:          PHYSICS_CYCLE,
+		NetworkCycle:          NETWORK_CYCLE,
+		PlayerMessageThrottle: PLAYER_MESSAGE_THROTTLE,
+		PuckRadius:            PUCK_RADIUS,
+		MalletRadius:          MALLET_RADIUS,
+		PuckMass:              PUCK_MASS,
+		MalletMass:            MALLET_MASS,
+		MalletRestitution:     MALLET_RESTITUTION,
+		PuckRestitution:       PUCK_RESTITUTION,
+		WallRestitution:       WALL_RESTITUTION,
+		MalletFriction:        MALLET_FRICTION,
+		WallFriction:          WALL_FRICTION,
+		MalletMaxSpeed:        MALLET_MAX_SPEED,
+		MalletAcceleration:    MALLET_ACCELERATION,
+		StuckSpeed:            STUCK_SPEED,
+		StuckTime:             STUCK_TIME,
+		StuckRule:             STUCK_AWARD,
+		StuckNudgeSpeed:       STUCK_NUDGE_SPEED,
+		RestartPolicy:         RESTART_CONCEDER,
+		ServeTime:             SERVE_TIME,
+		ServeReleaseSpeed:     SERVE_RELEASE_SPEED,
+		PossessionTime:        POSSESSION_TIME,
+		PowerUpRadius:         POWERUP_RADIUS,
+		PowerUpInterval:       POWERUP_INTERVAL,
+		PowerUpLifetime:       POWERUP_LIFETIME,
+		PowerUpDuration:       POWERUP_DURATION,
+		FreezeTime:            FREEZE_TIME,
+		CountdownTime:         COUNTDOWN_TIME,
+		GoalFreezeTime:        GOAL_FREEZE_TIME,
+		ResumeTime:            RESUME_TIME,
+		PausesPerPlayer:       PAUSES_PER_PLAYER,
+		PauseTime:             PAUSE_TIME,
+		PauseNetworkCycle:     PAUSE_NETWORK_CYCLE,
+		ReconnectTime:         RECONNECT_TIME,
+		BotBackfillTime:       BOT_BACKFILL_TIME,
+		TeamSize:              TEAM_SIZE,
+		TeamZone:              TEAM_ZONE_FREE,
+	}
+}
+
+func RulesPreset(name string) (GameRules, error) {
+	rules := DefaultRules()
+
+	switch name {
+	case RULES_CASUAL:
+		rules.MaxGoals = 7
+		rules.WinBy = 2
+		rules.MaxPuckMagnitude = 1.5
+		rules.Drag = 0.015
+		rules.MalletMaxSpeed = 2
+		rules.MalletAcceleration = 0.02
+		rules.StuckRule = STUCK_NUDGE
+		rules.RestartPolicy = RESTART_ALTERNATE
+		rules.ServeTime = 10000
+		rules.PossessionTime = 10000
+	case RULES_RANKED:
+	case RULES_ARCADE:
+		rules.MaxGoals = 0
+		rules.TimeLimit = 180000
+		rules.Overtime = true
+		rules.PuckCount = 3
+		rules.PuckRestitution = 1
+		rules.MaxPuckMagnitude = 3
+		rules.Drag = 0.005
+		rules.WallRestitution = 1
+		rules.MalletRestitution = 1
+		rules.MalletFriction = 0.5
+		rules.MalletMaxSpeed = 3.5
+		rules.MalletAcceleration = 0.05
+		rules.StuckTime = 2000
+		rules.StuckRule = STUCK_NUDGE
+		rules.StuckNudgeSpeed = 1.2
+		rules.GoalFreezeTime = 1000
+		rules.PausesPerPlayer = 1
+		rules.RestartPolicy = RESTART_FACEOFF
+		rules.PossessionTime = 0
+		rules.Obstacles = []Obstacle{
+			arcadeBumper(-300, []Vector{NewVector(-200, 0), NewVector(200, 0)}),
+			arcadeBumper(300, []Vector{NewVector(200, 0), NewVector(-200, 0)}),
+		}
+		rules.PowerUps = POWERUP_TYPES
+	case RULES_CRAZY:
+		rules.Rink = "crazy"
+		rules.MaxGoals = 5
+		rules.Sets = 3
+		rules.RestartPolicy = RESTART_FACEOFF
+		rules.StuckRule = STUCK_NUDGE
+		rules.PossessionTime = 0
+	case RULES_DOUBLES:
+		rules.MaxGoals = 7
+		rules.TeamSize = 2
+		rules.StuckRule = STUCK_NUDGE
+		rules.RestartPolicy = RESTART_FACEOFF
+		rules.PausesPerPlayer = 1
+	default:
+		return rules, errors.New("unknown rules preset " + name)
+	}
+
+	rules.Name = name
+
+	return rules, rules.Validate()
+}
+
+func arcadeBumper(y float64, path []Vector) Obstacle {
+	return Obstacle{
+		Type:        OBSTACLE_BUMPER,
+		Center:      NewVector(0, y),
+		Radius:      30,
+		Restitution: 1.2,
+		Path:        path,
+		Speed:       0.15,
+	}
+}
+
+func (rules GameRules) Validate() error {
+	if rules.PhysicsStep <= 0 || rules.PhysicsCycle <= 0 || rules.NetworkCycle <= 0 {
+		return errors.New("rules " + rules.Name + ": physics step and cycles must be positive")
+	}
+
+	if rules.PlayerMessageThrottle < 0 {
+		return errors.New("rules " + rules.Name + ": player message throttle is negative")
+	}
+
+	if rules.Drag < 0 || rules.Drag >= 1 || rules.SpinDrag < 0 || rules.SpinDrag >= 1 {
+		return errors.New("rules " + rules.Name + ": drag must be in [0, 1)")
+	}
+
+	if rules.MaxPuckMagnitude <= 0 {
+		return errors.New("rules " + rules.Name + ": puck speed must be positive")
+	}
+
+	if rules.TimeLimit < 0 || (rules.MaxGoals == 0 && rules.TimeLimit == 0) {
+		return errors.New("rules " + rules.Name + ": a set needs a goal limit or a time limit")
+	}
+
+	if rules.WinBy == 0 || rules.Sets%2 == 0 {
+		return errors.New("rules " + rules.Name + ": win margin must be positive and sets must be odd")
+	}
+
+	if rules.Overtime && rules.TimeLimit == 0 {
+		return errors.New("rules " + rules.Name + ": overtime needs a time limit")
+	}
+
+	if rules.PuckCount < 1 || rules.PuckCount > MAX_PUCKS {
+		return errors.New("rules " + rules.Name + ": puck count must be between 1 and " + strconv.Itoa(MAX_PUCKS))
+	}
+
+	if rules.PuckRadius <= 0 || rules.MalletRadius <= 0 || rules.PuckMass <= 0 || rules.MalletMass <= 0 {
+		return errors.New("rules " + rules.Name + ": radii and masses must be positive")
+	}
+
+	if rules.MalletRestitution < 0 || rules.MalletRestitution > 1 || rules.WallRestitution < 0 || rules.WallRestitution > 1 ||
+		rules.PuckRestitution < 0 || rules.PuckRestitution > 1 {
+		return errors.New("rules " + rules.Name + ": restitution must be in [0, 1]")
+	}
+
+	if rules.MalletFriction < 0 || rules.WallFriction < 0 {
+		return errors.New("rules " + rules.Name + ": friction is negative")
+	}
+
+	if rules.MalletMaxSpeed <= 0 || rules.MalletAcceleration <= 0 {
+		return errors.New("rules " + rules.Name + ": mallet speed and acceleration must be positive")
+	}
+
+	if rules.StuckSpeed < 0 || rules.StuckTime <= 0 || rules.StuckNudgeSpeed <= 0 {
+		return errors.New("rules " + rules.Name + ": stuck puck limits must be positive")
+	}
+
+	switch rules.StuckRule {
+	case STUCK_NUDGE, STUCK_AWARD, STUCK_SERVE:
+	default:
+		return errors.New("rules " + rules.Name + ": unknown stuck puck rule " + rules.StuckRule)
+	}
+
+	switch rules.RestartPolicy {
+	case RESTART_FACEOFF, RESTART_CONCEDER, RESTART_ALTERNATE:
+	default:
+		return errors.New("rules " + rules.Name + ": unknown restart policy " + rules.RestartPolicy)
+	}
+
+	if rules.CountdownTime < 0 || rules.GoalFreezeTime < 0 || rules.ResumeTime < 0 {
+		return errors.New("rules " + rules.Name + ": phase durations are negative")
+	}
+
+	if rules.PausesPerPlayer < 0 || (rules.PausesPerPlayer > 0 && rules.PauseTime <= 0) || rules.PauseNetworkCycle <= 0 {
+		return errors.New("rules " + rules.Name + ": pause limits must be positive")
+	}
+
+	if rules.ReconnectTime < 0 || rules.BotBackfillTime < 0 {
+		return errors.New("rules " + rules.Name + ": reconnect and bot backfill times are negative")
+	}
+
+	if rules.TeamSize < 1 || rules.TeamSize > MAX_TEAM_SIZE {
+		return errors.New("rules " + rules.Name + ": team size must be between 1 and " + strconv.Itoa(MAX_TEAM_SIZE))
+	}
+
+	switch rules.TeamZone {
+	case TEAM_ZONE_FREE, TEAM_ZONE_LANES:
+	default:
+		return errors.New("rules " + rules.Name + ": unknown team zone " + rules.TeamZone)
+	}
+
+	if rules.ServeTime <= 0 || rules.ServeReleaseSpeed <= 0 {
+		return errors.New("rules " + rules.Name + ": serve time and release speed must be positive")
+	}
+
+	if rules.PossessionTime < 0 {
+		return errors.New("rules " + rules.Name + ": possession time is negative")
+	}
+
+	for _, powerUp := range rules.PowerUps {
+		if !slices.Contains(POWERUP_TYPES, powerUp) {
+			return errors.New("rules " + rules.Name + ": unknown power-up " + powerUp)
+		}
+	}
+
+	if len(rules.PowerUps) > 0 && (rules.PowerUpRadius <= 0 || rules.PowerUpInterval <= 0 || rules.PowerUpLifetime <= 0 ||
+		rules.PowerUpDuration <= 0 || rules.FreezeTime <= 0) {
+		return errors.New("rules " + rules.Name + ": power-up radius and times must be positive")
+	}
+
+	return nil
+}
+
+func (rules GameRules) ValidateRink(rink *Rink) error {
+	radius := float64(rules.MalletRadius)
+	if slices.Contains(rules.PowerUps, POWERUP_GROW) {
+		radius = math.Round(radius * POWERUP_GROW_SCALE)
+	}
+
+	for i := 0; i < 2*rules.TeamSize; i++ {
+		side, member := MalletSide(rules, i)
+		zone := MalletZone(rink, rules, side, member)
+		if zone.Max.X-zone.Min.X < 2*radius || zone.Max.Y-zone.Min.Y < 2*radius {
+			return errors.New("rules " + rules.Name + ": mallet does not fit rink " + rink.Name)
+		}
+	}
+
+	return nil
+}
+
+func (rules GameRules) StepDrag(drag float64, dt float64) float64 {
+	return math.Pow(1-drag, dt/float64(rules.PhysicsCycle))
+}
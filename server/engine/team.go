@@ -0,0 +1,106 @@
+package engine
+
+const MAX_TEAM_SIZE = 2
+const MAX_MALLETS = 2 * MAX_TEAM_SIZE
+
+// MalletZone is where the member of the side's team may move, the whole half
+// or, when teams keep to lanes, the member's lane of it counted from the left of the rink.
+func MalletZone(rink *Rink, rules GameRules, side Side, member int) Zone {
+	zone := rink.MalletA
+	if side == SIDE_B {
+		zone = rink.MalletB
+	}
+
+	if rules.TeamZone != TEAM_ZONE_LANES {
+		return zone
+	}
+
+	width := (zone.Max.X - zone.Min.X) / float64(rules.TeamSize)
+	zone.Min.X += width * float64(member)
+	zone.Max.X = zone.Min.X + width
+
+	return zone
+}
+
+// MalletSide tells the team of the mallet and its place in it, side A's team comes first.
+func MalletSide(rules GameRules, index int) (Side, int) {
+	if index < rules.TeamSize {
+		return SIDE_A, index
+	}
+
+	return SIDE_B, index - rules.TeamSize
+}
+
+// newMallets spreads each team across the back of its half.
+func newMallets(rink *Rink, rules GameRules) [MAX_MALLETS]Mallet {
+	var mallets [MAX_MALLETS]Mallet
+
+	for i := 0; i < 2*rules.TeamSize; i++ {
+		side, member := MalletSide(rules, i)
+
+		half := rink.MalletA
+		y := half.Max.Y - float64(2*rules.MalletRadius)
+		if side == SIDE_B {
+			half = rink.MalletB
+			y = half.Min.Y + float64(2*rules.MalletRadius)
+		}
+
+		x := Lerp(half.Min.X, half.Max.X, (float64(member)+0.5)/float64(rules.TeamSize))
+		mallets[i] = newMallet(NewVector(x, y), side, MalletZone(rink, rules, side, member))
+	}
+
+	return mallets
+}
+
+func (world *World) ActiveMallets() []Mallet {
+	return world.Mallets[:world.MalletCount]
+}
+
+// separateMallets pushes overlapping teammates apart, each giving way by half,
+// mallets of different teams never meet as they keep to their own halves.
+func (world *World) separateMallets(dt float64) {
+	for i := 0; i < world.MalletCount; i++ {
+		for j := i + 1; j < world.MalletCount; j++ {
+			first, second := &world.Mallets[i], &world.Mallets[j]
+			if first.Side != second.Side {
+				continue
+			}
+
+			radius := float64(world.Modifiers(first.Side).MalletRadius)
+			between := SubstractVectors(second.Position, first.Position)
+			distance := VectorLength(between)
+			if distance >= 2*radius {
+				continue
+			}
+
+			direction := NewVector(1, 0)
+			if distance > EPSILON {
+				direction = MultiplyVectorNumber(between, 1/distance)
+			}
+
+			push := MultiplyVectorNumber(direction, (2*radius-distance)/2)
+			first.Position = first.Zone.ClampPoint(SubstractVectors(first.Position, push), radius)
+			second.Position = second.Zone.ClampPoint(SumVectors(second.Position, push), radius)
+
+			first.Magnitude = MultiplyVectorNumber(SubstractVectors(first.Position, first.PrevPosition), 1/dt)
+			second.Magnitude = MultiplyVectorNumber(SubstractVectors(second.Position, second.PrevPosition), 1/dt)
+		}
+	}
+}
+
+// closestMallet returns where the side's mallet nearest to the point is.
+func (world *World) closestMallet(side Side, point Vector) Vector {
+	closest, best := point, -1.0
+
+	for _, mallet := range world.ActiveMallets() {
+		if mallet.Side != side {
+			continue
+		}
+
+		if distance := DistanceBetweenPoints(mallet.Position, point); best < 0 || distance < best {
+			closest, best = mallet.Position, distance
+		}
+	}
+
+	return closest
+}
@@ -0,0 +1,600 @@
+package engine
+
+import "math"
+
+const COLLISION_DISTANCE = 0.1
+const MAX_COLLISION_ITERATIONS = 4
+const MAX_PUCKS = 6
+const MAX_STEP_EVENTS = MAX_PUCKS*(MAX_COLLISION_ITERATIONS+4) + MAX_POWERUPS + 1
+
+type Side int
+
+const SIDE_NONE Side = 0
+const SIDE_A Side = 1
+const SIDE_B Side = 2
+
+type EventType string
+
+const EVENT_GOAL EventType = "GOAL"
+const EVENT_WALL_HIT EventType = "WALL_HIT"
+const EVENT_POST_HIT EventType = "POST_HIT"
+const EVENT_MALLET_HIT EventType = "MALLET_HIT"
+const EVENT_PUCK_HIT EventType = "PUCK_HIT"
+const EVENT_OBSTACLE_HIT EventType = "OBSTACLE_HIT"
+const EVENT_STUCK_PUCK EventType = "STUCK_PUCK"
+const EVENT_SERVE EventType = "SERVE"
+const EVENT_FOUL EventType = "FOUL"
+const EVENT_POWERUP EventType = "POWERUP"
+const EVENT_OVERTIME EventType = "OVERTIME"
+const EVENT_SET_END EventType = "SET_END"
+const EVENT_MATCH_END EventType = "MATCH_END"
+
+func (side Side) Opponent() Side {
+	switch side {
+	case SIDE_A:
+		return SIDE_B
+	case SIDE_B:
+		return SIDE_A
+	default:
+		return SIDE_NONE
+	}
+}
+
+// Puck is the index of the puck the event happened to, Mallet the index of the mallet that hit it,
+// PowerUp is the type of a collected power-up.
+type Event struct {
+	Type    EventType
+	Side    Side
+	Puck    int
+	Mallet  int
+	PowerUp string
+}
+
+type Position struct {
+	X int
+	Y int
+}
+
+func NewPosition(x, y int) Position {
+	return Position{X: x, Y: y}
+}
+
+func RoundPosition(point Vector) Position {
+	return Position{X: int(math.Round(point.X)), Y: int(math.Round(point.Y))}
+}
+
+// Mallet follows its Target, the last position the player asked for,
+// with speed and acceleration limited by the rules. It plays for Side and never leaves Zone.
+type Mallet struct {
+	Position     Vector
+	PrevPosition Vector
+	Target       Vector
+	Magnitude    Vector
+	Side         Side
+	Zone         Zone
+}
+
+// StuckTime is how long, in milliseconds, the puck has lain still out of reach or been pinned by a mallet.
+// HoldTime is how long it has stayed in Holder's half, LastHit is the side whose mallet touched it last.
+type Puck struct {
+	Position  Vector
+	Magnitude Vector
+	Spin      float64
+	StuckTime float64
+	Holder    Side
+	HoldTime  float64
+	LastHit   Side
+	Serve     Serve
+}
+
+// Inputs are the positions asked for the mallets by their index, nil leaves the mallet's target as it was.
+type Inputs [MAX_MALLETS]*Position
+
+// Mallets are a fixed array with side A's team first, only the first MalletCount are in play.
+// Pucks is a fixed array so that copying the world copies them too, only the first PuckCount are in play.
+// Obstacles are never changed, moving ones are placed by Time, the milliseconds the world has run.
+// Power-ups and effects are fixed arrays too, the modifiers are worked out from the effects every step.
+type World struct {
+	Mallets      [MAX_MALLETS]Mallet
+	MalletCount  int
+	Pucks        [MAX_PUCKS]Puck
+	PuckCount    int
+	ScoreA       uint
+	ScoreB       uint
+	Match        Match
+	LastServe    Side
+	Time         float64
+	Obstacles    []Obstacle
+	PowerUps     [MAX_POWERUPS]PowerUp
+	PowerUpCount int
+	NextPowerUp  float64
+	Effects      [MAX_EFFECTS]Effect
+	EffectCount  int
+	ModifiersA   Modifiers
+	ModifiersB   Modifiers
+	Random       uint64
+	Rink         *Rink
+	Rules        GameRules
+}
+
+func NewWorld(rink *Rink, rules GameRules) World {
+	world := World{
+		Mallets:     newMallets(rink, rules),
+		MalletCount: 2 * rules.TeamSize,
+		PuckCount:   rules.PuckCount,
+		ScoreA:      0,
+		ScoreB:      0,
+		Match:       newMatch(rules),
+		Obstacles:   append(append([]Obstacle{}, rink.Obstacles...), ModeObstacles(rink, rules.Obstacles)...),
+		NextPowerUp: rules.PowerUpInterval,
+		ModifiersA:  NewModifiers(rules),
+		ModifiersB:  NewModifiers(rules),
+		Rink:        rink,
+		Rules:       rules,
+	}
+
+	for i := 0; i < world.PuckCount; i++ {
+		world.resetPuck(i, rink.Faceoff)
+	}
+
+	return world
+}
+
+func newMallet(position Vector, side Side, zone Zone) Mallet {
+	return Mallet{
+		Position:     position,
+		PrevPosition: position,
+		Target:       position,
+		Magnitude:    NewVector(0, 0),
+		Side:         side,
+		Zone:         zone,
+	}
+}
+
+// Clone returns an independent copy of the world, the rink is shared as it never changes.
+func (world World) Clone() World {
+	return world
+}
+
+func (world *World) ActivePucks() []Puck {
+	return world.Pucks[:world.PuckCount]
+}
+
+// Step returns the world advanced by dt milliseconds, the given world is left untouched.
+// Same world, inputs and dt always give the same result, a finished match does not change any more.
+// Events are appended to the given slice, so callers can reuse one buffer between steps.
+func Step(world World, inputs Inputs, dt float64, events []Event) (World, []Event) {
+	next := world.Clone()
+	if next.Match.Over {
+		return next, events
+	}
+
+	next.Time += dt
+	events = next.stepClock(dt, events)
+	if next.Match.Over {
+		return next, events
+	}
+	next.updateEffects(dt)
+
+	for i := 0; i < next.MalletCount; i++ {
+		mallet := &next.Mallets[i]
+		mallet.move(next.Rules, next.Modifiers(mallet.Side), inputs[i], dt)
+	}
+	next.separateMallets(dt)
+
+	for i := 0; i < next.PuckCount && !next.Match.Over; i++ {
+		events = next.stepPuck(i, dt, events)
+	}
+
+	events = next.stepPowerUps(dt, events)
+
+	return next, events
+}
+
+// stepPuck moves one puck and applies the rules to it, pucks that already moved this step
+// are met where they ended up.
+func (world *World) stepPuck(index int, dt float64, events []Event) []Event {
+	puck := &world.Pucks[index]
+
+	count := len(events)
+	events = world.movePuck(index, dt, events)
+
+	held, pinned := world.separatePuck(index, dt)
+	for _, event := range events[count:] {
+		switch event.Type {
+		case EVENT_MALLET_HIT:
+			held = true
+		case EVENT_WALL_HIT, EVENT_POST_HIT, EVENT_OBSTACLE_HIT:
+			pinned = true
+		}
+	}
+
+	puck.Magnitude = MultiplyVectorNumber(puck.Magnitude, world.Rules.StepDrag(world.Rules.Drag, dt))
+	puck.Spin *= world.Rules.StepDrag(world.Rules.SpinDrag, dt)
+
+	if puck.Serve.Side != SIDE_NONE {
+		puck.StuckTime = 0
+		puck.Serve.TimeLeft -= dt
+
+		if hitBy(events[count:], puck.Serve.Side) {
+			puck.Serve = Serve{}
+		} else if puck.Serve.TimeLeft <= 0 {
+			world.releaseServe(index)
+		}
+	} else if (held && pinned) || (!held && VectorLength(puck.Magnitude) < world.Rules.StuckSpeed) {
+		// Only a puck lying out of reach or one a mallet presses into the rink is stuck,
+		// one a mallet plays freely is left to the possession clock.
+		puck.StuckTime += dt
+	} else {
+		puck.StuckTime = 0
+	}
+
+	if scorer := detectGoal(world.Rink, puck.Position); scorer != SIDE_NONE {
+		if scorer == SIDE_A {
+			world.ScoreA++
+		} else {
+			world.ScoreB++
+		}
+
+		events = append(events, Event{Type: EVENT_GOAL, Side: scorer, Puck: index})
+
+		var ended bool
+		if events, ended = world.checkSet(scorer, events); !ended {
+			events = world.restart(index, scorer.Opponent(), events)
+		}
+	} else if puck.StuckTime >= world.Rules.StuckTime || !world.Rink.Contains(puck.Position) {
+		events = world.freePuck(index, events)
+	} else if world.Rules.PossessionTime > 0 {
+		events = world.checkPossession(index, dt, events)
+	}
+
+	return events
+}
+
+// A frozen mallet keeps still but remembers the target to head for once it thaws.
+func (mallet *Mallet) move(rules GameRules, modifiers Modifiers, input *Position, dt float64) {
+	mallet.PrevPosition = mallet.Position
+	if input != nil {
+		mallet.Target = NewVector(float64(input.X), float64(input.Y))
+	}
+
+	radius := float64(modifiers.MalletRadius)
+	if modifiers.Frozen {
+		mallet.Magnitude = NewVector(0, 0)
+		mallet.Position = mallet.Zone.ClampPoint(mallet.Position, radius)
+
+		return
+	}
+
+	// Head for the target as fast as allowed, but slow enough to stop on it.
+	toTarget := SubstractVectors(mallet.Target, mallet.Position)
+	distance := VectorLength(toTarget)
+	desired := NewVector(0, 0)
+	if distance > EPSILON {
+		speed := math.Min(rules.MalletMaxSpeed, math.Min(math.Sqrt(2*rules.MalletAcceleration*distance), distance/dt))
+		desired = MultiplyVectorNumber(toTarget, speed/distance)
+	}
+
+	change := SubstractVectors(desired, mallet.Magnitude)
+	if limit := rules.MalletAcceleration * dt; VectorLength(change) > limit {
+		change = ResizeVector(change, limit)
+	}
+
+	mallet.Magnitude = SumVectors(mallet.Magnitude, change)
+	mallet.Position = mallet.Zone.ClampPoint(SumVectors(mallet.Position, MultiplyVectorNumber(mallet.Magnitude, dt)), radius)
+	mallet.Magnitude = MultiplyVectorNumber(SubstractVectors(mallet.Position, mallet.PrevPosition), 1/dt)
+}
+
+func (world *World) movePuck(index int, dt float64, events []Event) []Event {
+	puck := &world.Pucks[index]
+	remaining := 1.0
+	shields, shieldCount := world.goalShields()
+
+	for i := 0; i < MAX_COLLISION_ITERATIONS && remaining > EPSILON; i++ {
+		elapsed := 1 - remaining
+		move := MultiplyVectorNumber(puck.Magnitude, dt*remaining)
+
+		toi := 1.0
+		collided := false
+		var normal Vector
+		var mallet *Mallet
+		var other *Puck
+		event := Event{Puck: index}
+
+		if hit, t, wallNormal := detectWallHit(world.Rules, world.Rink.Walls, puck.Position, move); hit && t < toi {
+			toi, collided, normal, event = t, true, wallNormal, Event{Type: EVENT_WALL_HIT, Puck: index}
+		}
+
+		if hit, t, wallNormal := detectWallHit(world.Rules, shields[:shieldCount], puck.Position, move); hit && t < toi {
+			toi, collided, normal, event = t, true, wallNormal, Event{Type: EVENT_WALL_HIT, Puck: index}
+		}
+
+		if hit, t, postNormal := detectPostHit(world.Rules, world.Rink.Posts, puck.Position, move); hit && t < toi {
+			toi, collided, normal, event = t, true, postNormal, Event{Type: EVENT_POST_HIT, Puck: index}
+		}
+
+		for j := 0; j < world.MalletCount; j++ {
+			hitter := &world.Mallets[j]
+			if hit, t, hitNormal := detectPlayerHit(world.Rules, hitter, world.Modifiers(hitter.Side).MalletRadius, elapsed, puck.Position, move); hit && t < toi {
+				toi, collided, normal, mallet, event = t, true, hitNormal, hitter, Event{Type: EVENT_MALLET_HIT, Side: hitter.Side, Puck: index, Mallet: j}
+			}
+		}
+
+		var obstacle *Obstacle
+		var obstacleMagnitude Vector
+		for j := range world.Obstacles {
+			start := world.Time - dt + elapsed*dt
+			from, to := world.Obstacles[j].Offset(start), world.Obstacles[j].Offset(world.Time)
+			obstacleMove := SubstractVectors(to, from)
+
+			if hit, t, hitNormal := world.Obstacles[j].Moved(from).Sweep(puck.Position, move, float64(world.Rules.PuckRadius), obstacleMove); hit && t < toi {
+				toi, collided, normal, mallet, event = t, true, hitNormal, nil, Event{Type: EVENT_OBSTACLE_HIT, Puck: index}
+				obstacle, obstacleMagnitude = &world.Obstacles[j], MultiplyVectorNumber(obstacleMove, 1/(dt*remaining))
+			}
+		}
+
+		for j := 0; j < world.PuckCount; j++ {
+			if j == index {
+				continue
+			}
+
+			if hit, t, hitNormal := detectPuckHit(world.Rules, puck.Position, move, world.Pucks[j].Position); hit && t < toi {
+				toi, collided, normal, mallet, obstacle, other, event = t, true, hitNormal, nil, nil, &world.Pucks[j], Event{Type: EVENT_PUCK_HIT, Puck: index}
+			}
+		}
+
+		puck.Position = SumVectors(puck.Position, MultiplyVectorNumber(move, toi))
+		if !collided {
+			break
+		}
+
+		if mallet != nil {
+			boost := world.Modifiers(event.Side).Boost
+			puck.Magnitude, puck.Spin = hitMallet(world.Rules, puck.Magnitude, puck.Spin, mallet.Magnitude, normal)
+			puck.Magnitude = validateMagnitute(MultiplyVectorNumber(puck.Magnitude, boost), world.Rules.MaxPuckMagnitude*boost)
+			puck.LastHit = event.Side
+		} else if obstacle != nil {
+			puck.Magnitude, puck.Spin = hitSurface(world.Rules, puck.Magnitude, puck.Spin, obstacleMagnitude, 0, normal, obstacle.Restitution, world.Rules.WallFriction)
+			puck.Magnitude = validateMagnitute(puck.Magnitude, world.Rules.MaxPuckMagnitude)
+		} else if other != nil {
+			hitPuck(world.Rules, puck, other, normal)
+		} else {
+			puck.Magnitude, puck.Spin = hitWall(world.Rules, puck.Magnitude, puck.Spin, normal)
+		}
+
+		puck.Position = SumVectors(puck.Position, MultiplyVectorNumber(normal, COLLISION_DISTANCE))
+		remaining *= 1 - toi
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// separatePuck moves the puck out of anything it overlaps, it reports whether a mallet
+// and whether anything fixed, an obstacle, a post or a wall, had to push it.
+// The rink has the last word: a wall never lets the puck through to its other side,
+// and a mallet pressing the puck into it is stopped where the puck is.
+func (world *World) separatePuck(index int, dt float64) (bool, bool) {
+	puck := &world.Pucks[index]
+	start := puck.Position
+	pushed, held, pinned := false, false, false
+
+	for j := 0; j < world.PuckCount; j++ {
+		if j != index {
+			puck.Position = pushOut(puck.Position, world.Pucks[j].Position, float64(2*world.Rules.PuckRadius), &pushed)
+		}
+	}
+
+	for i := 0; i < MAX_COLLISION_ITERATIONS; i++ {
+		moved := false
+		for _, mallet := range world.ActiveMallets() {
+			puck.Position = pushOut(puck.Position, mallet.Position, world.malletReach(mallet), &moved)
+		}
+
+		held = held || moved
+		if i > 0 && !moved {
+			break
+		}
+
+		puck.Position = world.pushOutOfRink(puck.Position, start, &pinned)
+	}
+
+	if held && pinned {
+		world.stopMallets(puck.Position, dt)
+	}
+
+	return held, pinned
+}
+
+// pushOutOfRink moves the point out of obstacles, posts, walls and goal shields,
+// walls send it back to the side of them it started the step on.
+func (world *World) pushOutOfRink(position Vector, start Vector, pinned *bool) Vector {
+	radius := float64(world.Rules.PuckRadius)
+
+	for _, obstacle := range world.Obstacles {
+		closest, distance := obstacle.Moved(obstacle.Offset(world.Time)).Closest(position, radius)
+		position = pushOut(position, closest, distance, pinned)
+	}
+
+	for _, post := range world.Rink.Posts {
+		position = pushOut(position, post.Center, radius+post.Radius, pinned)
+	}
+
+	for _, wall := range world.Rink.Walls {
+		position = pushOutOfWall(position, start, wall, radius, pinned)
+	}
+
+	shields, shieldCount := world.goalShields()
+	for _, wall := range shields[:shieldCount] {
+		position = pushOutOfWall(position, start, wall, radius, pinned)
+	}
+
+	return position
+}
+
+// stopMallets moves back every mallet that still overlaps a puck the rink holds in place.
+func (world *World) stopMallets(puck Vector, dt float64) {
+	for i := 0; i < world.MalletCount; i++ {
+		mallet := &world.Mallets[i]
+		reach := world.malletReach(*mallet)
+		if DistanceBetweenPoints(mallet.Position, puck) >= reach {
+			continue
+		}
+
+		// The mallet came from where it was, so that is the way back out.
+		away := SubstractVectors(mallet.PrevPosition, puck)
+		if VectorLength(away) < EPSILON {
+			away = SubstractVectors(mallet.Position, puck)
+		}
+
+		if VectorLength(away) < EPSILON {
+			continue
+		}
+
+		position := SumVectors(puck, ResizeVector(away, reach+COLLISION_DISTANCE))
+		mallet.Position = mallet.Zone.ClampPoint(position, float64(world.Modifiers(mallet.Side).MalletRadius))
+		mallet.Magnitude = MultiplyVectorNumber(SubstractVectors(mallet.Position, mallet.PrevPosition), 1/dt)
+	}
+}
+
+func (world *World) malletReach(mallet Mallet) float64 {
+	return float64(world.Rules.PuckRadius + world.Modifiers(mallet.Side).MalletRadius)
+}
+
+func pushOut(puck Vector, obstacle Vector, distance float64, pushed *bool) Vector {
+	current := DistanceBetweenPoints(puck, obstacle)
+	if current >= distance || current < EPSILON {
+		return puck
+	}
+
+	*pushed = true
+
+	return PointOnLine(obstacle, puck, distance+COLLISION_DISTANCE)
+}
+
+// pushOutOfWall keeps the puck clear of the wall on the side of it the puck started from,
+// even when something has already pushed its center through.
+func pushOutOfWall(puck Vector, start Vector, wall Wall, distance float64, pushed *bool) Vector {
+	crossed, crossing := CheckSegmentSegmentIntercection(start, puck, wall.Start, wall.End)
+	if !crossed {
+		return pushOut(puck, ClosestPointOnSegment(wall.Start, wall.End, puck), distance, pushed)
+	}
+
+	along := SubstractVectors(wall.End, wall.Start)
+	normal := NormalizeVector(NewVector(-along.Y, along.X))
+	if MultiplyVectors(SubstractVectors(start, wall.Start), normal) < 0 {
+		normal = MultiplyVectorNumber(normal, -1)
+	}
+
+	*pushed = true
+
+	return SumVectors(crossing, MultiplyVectorNumber(normal, distance+COLLISION_DISTANCE))
+}
+
+func hitWall(rules GameRules, magnitude Vector, spin float64, wallNormal Vector) (Vector, float64) {
+	return hitSurface(rules, magnitude, spin, NewVector(0, 0), 0, wallNormal, rules.WallRestitution, rules.WallFriction)
+}
+
+func hitMallet(rules GameRules, magnitude Vector, spin float64, playerMagnitude Vector, hitNormal Vector) (Vector, float64) {
+	return hitSurface(rules, magnitude, spin, playerMagnitude, 1/rules.MalletMass, hitNormal, rules.MalletRestitution, rules.MalletFriction)
+}
+
+// hitPuck bounces two pucks off each other, whatever one gains the other loses.
+func hitPuck(rules GameRules, puck *Puck, other *Puck, normal Vector) {
+	before := puck.Magnitude
+	puck.Magnitude, _ = hitSurface(rules, puck.Magnitude, 0, other.Magnitude, 1/rules.PuckMass, normal, rules.PuckRestitution, 0)
+	other.Magnitude = SubstractVectors(other.Magnitude, SubstractVectors(puck.Magnitude, before))
+}
+
+func hitSurface(
+	rules GameRules,
+	magnitude Vector,
+	spin float64,
+	surfaceMagnitude Vector,
+	surfaceInverseMass float64,
+	normal Vector,
+	restitution float64,
+	friction float64,
+) (Vector, float64) {
+	relative := SubstractVectors(magnitude, surfaceMagnitude)
+	approach := MultiplyVectors(relative, normal)
+	if approach >= 0 {
+		return magnitude, spin
+	}
+
+	inverseMass := 1/rules.PuckMass + surfaceInverseMass
+	normalImpulse := -(1 + restitution) * approach / inverseMass
+
+	radius := float64(rules.PuckRadius)
+	inertia := rules.PuckMass * Sqr(radius) / 2
+	tangent := NewVector(-normal.Y, normal.X)
+	slip := MultiplyVectors(relative, tangent) - radius*spin
+
+	limit := friction * normalImpulse
+	tangentImpulse := math.Max(-limit, math.Min(limit, -slip/(inverseMass+Sqr(radius)/inertia)))
+
+	impulse := SumVectors(MultiplyVectorNumber(normal, normalImpulse), MultiplyVectorNumber(tangent, tangentImpulse))
+
+	return SumVectors(magnitude, MultiplyVectorNumber(impulse, 1/rules.PuckMass)), spin - radius*tangentImpulse/inertia
+}
+
+func detectPlayerHit(rules GameRules, mallet *Mallet, radius int, elapsed float64, puck Vector, puckMove Vector) (bool, float64, Vector) {
+	position := LerpVector(mallet.PrevPosition, mallet.Position, elapsed)
+
+	return SweepCircles(
+		puck,
+		puckMove,
+		float64(rules.PuckRadius),
+		position,
+		SubstractVectors(mallet.Position, position),
+		float64(radius),
+	)
+}
+
+func detectPuckHit(rules GameRules, puck Vector, puckMove Vector, other Vector) (bool, float64, Vector) {
+	return SweepCircles(puck, puckMove, float64(rules.PuckRadius), other, NewVector(0, 0), float64(rules.PuckRadius))
+}
+
+func detectWallHit(rules GameRules, walls []Wall, puck Vector, puckMove Vector) (bool, float64, Vector) {
+	hit, toi, normal := false, 0.0, Vector{}
+	for _, wall := range walls {
+		collision, t, wallNormal := SweepCircleSegment(puck, puckMove, float64(rules.PuckRadius), wall.Start, wall.End)
+		if collision && (!hit || t < toi) {
+			hit, toi, normal = true, t, wallNormal
+		}
+	}
+
+	return hit, toi, normal
+}
+
+func detectPostHit(rules GameRules, posts []Post, puck Vector, puckMove Vector) (bool, float64, Vector) {
+	hit, toi, normal := false, 0.0, Vector{}
+	for _, post := range posts {
+		collision, t, postNormal := SweepCircles(puck, puckMove, float64(rules.PuckRadius), post.Center, NewVector(0, 0), post.Radius)
+		if collision && (!hit || t < toi) {
+			hit, toi, normal = true, t, postNormal
+		}
+	}
+
+	return hit, toi, normal
+}
+
+func detectGoal(rink *Rink, puckPosition Vector) Side {
+	if rink.GoalB.Contains(puckPosition) {
+		return SIDE_A
+	}
+
+	if rink.GoalA.Contains(puckPosition) {
+		return SIDE_B
+	}
+
+	return SIDE_NONE
+}
+
+func validateMagnitute(puckMag Vector, maxMagnitude float64) Vector {
+	if VectorLength(puckMag) <= maxMagnitude {
+		return puckMag
+	}
+
+	return ResizeVector(puckMag, maxMagnitude)
+}
@@ -0,0 +1,46 @@
+package engine
+
+import "testing"
+
+func BenchmarkStep(b *testing.B) {
+	rink := testRink(b, "classic")
+
+	// Without a goal or time limit the match never ends, so every step is a full one.
+	rules := DefaultRules()
+	rules.MaxGoals, rules.TimeLimit = 0, 0
+
+	world := NewWorld(rink, rules)
+	world.Pucks[0].Magnitude = NewVector(rules.MaxPuckMagnitude*0.8, -rules.MaxPuckMagnitude*0.6)
+
+	inputA := NewPosition(rink.Width/2, rink.Height-2*rules.MalletRadius)
+	inputB := NewPosition(rink.Width/2, 2*rules.MalletRadius)
+
+	events := make([]Event, 0, MAX_STEP_EVENTS)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		inputs := Inputs{}
+		if i%4 == 0 {
+			inputA.X = rink.Width/4 + i%(rink.Width/2)
+			inputB.X = rink.Width/4 + (i/2)%(rink.Width/2)
+			inputs = Inputs{&inputA, &inputB}
+		}
+
+		world, events = Step(world, inputs, rules.PhysicsStep, events[:0])
+		if world.Pucks[0].Magnitude.X == 0 && world.Pucks[0].Magnitude.Y == 0 {
+			world.Pucks[0].Magnitude = NewVector(rules.MaxPuckMagnitude*0.8, -rules.MaxPuckMagnitude*0.6)
+		}
+	}
+
+	b.StopTimer()
+
+	if world.Match.Over {
+		b.Fatal("match ended, the steps after it measured nothing")
+	}
+
+	stepsPerSecond := 1000 / rules.PhysicsStep
+	nsPerStep := float64(b.Elapsed().Nanoseconds()) / float64(b.N)
+	b.ReportMetric(1e9/(nsPerStep*stepsPerSecond), "rooms/core")
+}
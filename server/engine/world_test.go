@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func testRink(t testing.TB, name string) *Rink {
+	data, err := os.ReadFile("../rinks/" + name + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rink, err := ParseRink(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rink
+}
+
+// scriptedInputs moves both mallets along fixed paths, a new target every fourth step.
+func scriptedInputs(rink *Rink, step int) Inputs {
+	if step%4 != 0 {
+		return Inputs{}
+	}
+
+	inputA := NewPosition(rink.Width/4+(step*7)%(rink.Width/2), rink.Height-100-(step*3)%(rink.Height/3))
+	inputB := NewPosition(rink.Width/4+(step*5)%(rink.Width/2), 100+(step*11)%(rink.Height/3))
+
+	return Inputs{&inputA, &inputB}
+}
+
+func TestStepIsDeterministic(t *testing.T) {
+	for _, name := range []string{RULES_RANKED, RULES_CRAZY} {
+		rules, err := RulesPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rink := testRink(t, rules.Rink)
+		run := func() ([]World, [][]Event) {
+			world := NewWorld(rink, rules)
+			world.Seed(42)
+			world.Pucks[0].Magnitude = NewVector(1.3, -1.1)
+
+			var worlds []World
+			var events [][]Event
+			for step := 0; step < 6000; step++ {
+				var stepEvents []Event
+				world, stepEvents = Step(world, scriptedInputs(rink, step), rules.PhysicsStep, nil)
+				worlds = append(worlds, world)
+				events = append(events, stepEvents)
+			}
+
+			return worlds, events
+		}
+
+		firstWorlds, firstEvents := run()
+		secondWorlds, secondEvents := run()
+
+		for step := range firstWorlds {
+			if !reflect.DeepEqual(firstWorlds[step], secondWorlds[step]) || !reflect.DeepEqual(firstEvents[step], secondEvents[step]) {
+				t.Fatalf("%s: runs differ at step %d", name, step)
+			}
+		}
+	}
+}
+
+func TestPinnedPuckStaysInside(t *testing.T) {
+	rules, err := RulesPreset(RULES_RANKED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep the puck where it is pinned, the rules would free it otherwise.
+	rules.StuckTime, rules.PossessionTime = 1e9, 0
+	rink := testRink(t, rules.Rink)
+
+	cases := []struct {
+		name   string
+		puck   Vector
+		target Position
+	}{
+		{"side wall", NewVector(750, 900), NewPosition(800, 900)},
+		{"corner", NewVector(740, 1140), NewPosition(800, 1200)},
+		{"end wall", NewVector(150, 1150), NewPosition(150, 1200)},
+	}
+
+	for _, test := range cases {
+		world := NewWorld(rink, rules)
+		world.Pucks[0] = Puck{Position: test.puck}
+		world.Mallets[0].Position = NewVector(500, 900)
+
+		radius := float64(rules.PuckRadius)
+		for step := 0; step < 500; step++ {
+			world, _ = Step(world, Inputs{&test.target}, rules.PhysicsStep, nil)
+
+			position := world.Pucks[0].Position
+			if position.X < radius-1 || position.X > float64(rink.Width)-radius+1 || position.Y < radius-1 || position.Y > float64(rink.Height)-radius+1 {
+				t.Fatalf("%s: puck at %v after %d steps", test.name, position, step)
+			}
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
-	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	"blindwizard.ru/hockey/engine"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
@@ -20,13 +24,44 @@ var upgrader = websocket.Upgrader{
 
 const MESSAGE_TIMEOUT time.Duration = 10 * time.Second
 const PING_RATE time.Duration = 1 * time.Second
+const SHUTDOWN_TIME time.Duration = 1 * time.Second
 const SERVER_CRT string = "server.crt"
 const SERVER_KEY string = "server.key"
 
 var pool = NewPool()
+var rinks map[string]*engine.Rink
+var rulesPresets map[string]engine.GameRules
 
 func main() {
+	loadedRinks, err := LoadRinks()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rinks = loadedRinks
+
+	rulesPresets = make(map[string]engine.GameRules)
+	for _, name := range engine.RULES_PRESETS {
+		rules, err := engine.RulesPreset(name)
+		if err == nil {
+			rink, exists := rinks[rules.Rink]
+			if !exists {
+				log.Fatal("rules " + name + ": unknown rink " + rules.Rink)
+			}
+
+			err = rules.ValidateRink(rink)
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		rulesPresets[name] = rules
+	}
+
+	pool.OnMatchResult(logMatchResult)
 	go pool.Matchmaking()
+	go shutdownOnSignal()
 
 	http.HandleFunc("/ws", func(writer http.ResponseWriter, request *http.Request) {
 		handler(writer, request)
@@ -72,31 +107,59 @@ func handleConnection(connection *websocket.Conn) {
 	go playerRead(conn, player)
 	go playerWrite(conn, player)
 
+	pool.ReconnectPlayer(player)
 	pool.UpdateOnline()
 }
 
+// shutdownOnSignal ends every match before the server stops, so its players still get the result.
+func shutdownOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	log.Println("Shutting down")
+	pool.EndRooms("server is shutting down")
+
+	time.Sleep(SHUTDOWN_TIME)
+	os.Exit(0)
+}
+
+func logMatchResult(result MatchResult) {
+	log.Println("Match result: " + result.RoomId.String() + " - " + string(result.Reason) +
+		", winner " + RelativeSide(result.Winner, engine.SIDE_NONE) +
+		", score " + strconv.Itoa(int(result.ScoreA)) + ":" + strconv.Itoa(int(result.ScoreB)) +
+		", sets " + strconv.Itoa(int(result.SetsA)) + ":" + strconv.Itoa(int(result.SetsB)) +
+		", " + result.Duration.Round(time.Second).String())
+}
+
+// handleDisconnection keeps a player who is in a match around for the reconnect grace period.
 func handleDisconnection(conn *Connection) {
 	conn.conn.Close()
 
 	playerToDisconnect := pool.RemoveConnection(conn.id)
 	if playerToDisconnect != nil {
 		log.Println("Player disconnected: " + playerToDisconnect.id.String())
-		pool.RemovePlayer(playerToDisconnect.id)
+		playerToDisconnect.Disconnect()
+		if !pool.AwaitReconnect(playerToDisconnect) {
+			pool.RemovePlayer(playerToDisconnect.id)
+		}
+
 		pool.UpdateOnline()
 	}
 }
 
 func ping(player *Player) {
-	lastPing := time.Now()
+	closed := player.Closed()
+	ticker := time.NewTicker(PING_RATE)
+	defer ticker.Stop()
 
 	for {
-		if time.Since(lastPing) < PING_RATE {
-			continue
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			player.Send(NewPingMessage(player.latency))
 		}
-
-		player.GetWrite() <- NewPingMessage(player.latency)
-
-		lastPing = time.Now()
 	}
 }
 
@@ -116,22 +179,41 @@ func playerRead(conn *Connection, player *Player) {
 
 		switch val := message.(type) {
 		case QueueMessage:
-			pool.QueuePlayer(player)
+			pool.QueuePlayer(player, val.rules, val.bot)
+		case BotMessage:
+			pool.StartBotMatch(player, val.rules, val.difficulty)
 		case UnQueueMessage:
 			pool.UnQueuePlayer(player)
 		case PongMessage:
 			player.CalcLatency(val)
 		case ExitGameMessage:
-			pool.DeleteRoom(player.currentRoomId, errors.New(val.reason))
-		case PlayerActionMessage:
+			if pool.Spectating(player) {
+				pool.RemoveSpectator(player)
+
+				continue
+			}
+
+			pool.DeleteRoom(pool.CurrentRoomId(player), NewMatchEnd(END_FORFEIT, player, val.reason))
+		case SpectateMessage:
+			pool.AddSpectator(player, val.roomId)
+		case PlayerActionMessage, PauseMessage, ResumeMessage:
+			if pool.Spectating(player) || pool.CurrentRoomId(player) == uuid.Nil {
+				continue
+			}
+
 			player.GetInput() <- message
 		}
 	}
 }
 
 func playerWrite(conn *Connection, player *Player) {
+	write, closed := player.GetWrite(), player.Closed()
+
 	for {
-		for message := range player.GetWrite() {
+		select {
+		case <-closed:
+			return
+		case message := <-write:
 			if message == nil {
 				continue
 			}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"log"
 	"strconv"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"blindwizard.ru/hockey/engine"
 	"github.com/google/uuid"
 )
 
@@ -16,11 +18,24 @@ const PING = "PING"
 const PONG = "PONG"
 const ONLINE = "ONLINE"
 const QUEUE = "QUEUE"
+const BOT = "BOT"
 const UNQUEUE = "UNQUEUE"
 const GAME = "GAME"
 const PLAYERACTION = "PLAYERACTION"
 const WORLD = "WORLD"
 const EXITGAME = "EXITGAME"
+const EVENT = "EVENT"
+const PAUSE = "PAUSE"
+const RESUME = "RESUME"
+const SPECTATE = "SPECTATE"
+const SPECTATORS = "SPECTATORS"
+const RESULT = "RESULT"
+
+const EVENT_SIDE_NONE = "none"
+const EVENT_SIDE_PLAYER = "player"
+const EVENT_SIDE_OPPONENT = "opponent"
+const EVENT_SIDE_A = "a"
+const EVENT_SIDE_B = "b"
 
 type ClientMessage interface{}
 
@@ -58,7 +73,25 @@ func ParseMessage(body []byte) ClientMessage {
 
 		return NewPongMessage(timestamp)
 	case QUEUE:
-		return NewQueueMessage()
+		if len(parts) < 2 {
+			return NewQueueMessage(engine.DEFAULT_RULES, "")
+		}
+
+		if len(parts) < 3 {
+			return NewQueueMessage(parts[1], "")
+		}
+
+		return NewQueueMessage(parts[1], parts[2])
+	case BOT:
+		if len(parts) < 2 {
+			return NewBotMessage(engine.DEFAULT_RULES, DEFAULT_BOT)
+		}
+
+		if len(parts) < 3 {
+			return NewBotMessage(parts[1], DEFAULT_BOT)
+		}
+
+		return NewBotMessage(parts[1], parts[2])
 	case UNQUEUE:
 		return NewUnQueueMessage()
 	case PLAYERACTION:
@@ -77,6 +110,22 @@ func ParseMessage(body []byte) ClientMessage {
 		return NewPlayerActionMessage(x, y)
 	case EXITGAME:
 		return NewExitGameMessage(parts[1])
+	case PAUSE:
+		return NewPauseMessage()
+	case RESUME:
+		return NewResumeMessage()
+	case SPECTATE:
+		if len(parts) < 2 {
+			return nil
+		}
+
+		roomId, err := uuid.Parse(parts[1])
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+
+		return NewSpectateMessage(roomId)
 	default:
 		return nil
 	}
@@ -132,10 +181,23 @@ func (message OnlineMessage) Stringify() []byte {
 	return []byte(ONLINE + ":" + strconv.Itoa(message.count))
 }
 
-type QueueMessage struct{}
+// Bot is the difficulty of the bot the player agrees to play when nobody else comes, empty for none.
+type QueueMessage struct {
+	rules string
+	bot   string
+}
 
-func NewQueueMessage() QueueMessage {
-	return QueueMessage{}
+func NewQueueMessage(rules string, bot string) QueueMessage {
+	return QueueMessage{rules: rules, bot: bot}
+}
+
+type BotMessage struct {
+	rules      string
+	difficulty string
+}
+
+func NewBotMessage(rules string, difficulty string) BotMessage {
+	return BotMessage{rules: rules, difficulty: difficulty}
 }
 
 type UnQueueMessage struct{}
@@ -153,18 +215,105 @@ func NewPlayerActionMessage(x int, y int) PlayerActionMessage {
 	return PlayerActionMessage{x: x, y: y}
 }
 
-type GameMessage struct {
+type PauseMessage struct{}
+
+func NewPauseMessage() PauseMessage {
+	return PauseMessage{}
+}
+
+type ResumeMessage struct{}
+
+func NewResumeMessage() ResumeMessage {
+	return ResumeMessage{}
+}
+
+type SpectateMessage struct {
 	roomId uuid.UUID
 }
 
-func NewGameMessage(roomId uuid.UUID) GameMessage {
+func NewSpectateMessage(roomId uuid.UUID) SpectateMessage {
+	return SpectateMessage{roomId: roomId}
+}
+
+type SpectatorsMessage struct {
+	count int
+}
+
+func NewSpectatorsMessage(count int) SpectatorsMessage {
+	return SpectatorsMessage{count: count}
+}
+
+func (message SpectatorsMessage) Stringify() []byte {
+	return []byte(SPECTATORS + ":" + strconv.Itoa(message.count))
+}
+
+type RinkDescription struct {
+	Name         string                `json:"name"`
+	Width        int                   `json:"width"`
+	Height       int                   `json:"height"`
+	Faceoff      [2]float64            `json:"faceoff"`
+	Walls        [][4]float64          `json:"walls"`
+	Posts        [][3]float64          `json:"posts"`
+	PlayerGoal   [4]float64            `json:"playerGoal"`
+	OpponentGoal [4]float64            `json:"opponentGoal"`
+	PlayerZone   [4]float64            `json:"playerZone"`
+	OpponentZone [4]float64            `json:"opponentZone"`
+	Obstacles    []ObstacleDescription `json:"obstacles"`
+}
+
+type ObstacleDescription struct {
+	Type   string     `json:"type"`
+	Center [2]float64 `json:"center"`
+	Radius float64    `json:"radius"`
+	From   [2]float64 `json:"from"`
+	To     [2]float64 `json:"to"`
+}
+
+type GameSettings struct {
+	Rink          RinkDescription `json:"rink"`
+	Rules         string          `json:"rules"`
+	PuckRadius    int             `json:"puckRadius"`
+	MalletRadius  int             `json:"malletRadius"`
+	MaxGoals      uint            `json:"maxGoals"`
+	WinBy         uint            `json:"winBy"`
+	Sets          uint            `json:"sets"`
+	TimeLimit     float64         `json:"timeLimit"`
+	Overtime      bool            `json:"overtime"`
+	Pauses        int             `json:"pauses"`
+	PauseTime     float64         `json:"pauseTime"`
+	Spectator     bool            `json:"spectator"`
+	PhysicsStep   float64         `json:"physicsStep"`
+	PhysicsCycle  int             `json:"physicsCycle"`
+	NetworkCycle  int             `json:"networkCycle"`
+	InputCycle    int             `json:"inputCycle"`
+	StuckRule     string          `json:"stuckRule"`
+	PowerUps      []string        `json:"powerUps"`
+	PowerUpRadius int             `json:"powerUpRadius"`
+	TeamSize      int             `json:"teamSize"`
+	TeamZone      string          `json:"teamZone"`
+}
+
+type GameMessage struct {
+	roomId   uuid.UUID
+	settings GameSettings
+}
+
+func NewGameMessage(roomId uuid.UUID, settings GameSettings) GameMessage {
 	return GameMessage{
-		roomId: roomId,
+		roomId:   roomId,
+		settings: settings,
 	}
 }
 
 func (message GameMessage) Stringify() []byte {
-	return []byte(GAME + ":" + message.roomId.String())
+	settings, err := json.Marshal(message.settings)
+	if err != nil {
+		log.Println(err)
+
+		return []byte(GAME + ":" + message.roomId.String())
+	}
+
+	return []byte(GAME + ":" + message.roomId.String() + ":" + string(settings))
 }
 
 type ExitGameMessage struct {
@@ -188,34 +337,265 @@ func (message ExitGameMessage) Stringify() []byte {
 	return []byte(EXITGAME + ":" + message.reason)
 }
 
+// ResultPlayer leaves out the player's id, it is what a player reconnects with.
+type ResultPlayer struct {
+	Team   string `json:"team"`
+	Self   bool   `json:"self"`
+	Goals  int    `json:"goals"`
+	Hits   int    `json:"hits"`
+	Pauses int    `json:"pauses"`
+}
+
+// ResultDescription is the result from the viewer's end, the viewer's team scores first and spectators see side A's first.
+type ResultDescription struct {
+	Reason   EndReason      `json:"reason"`
+	Winner   string         `json:"winner"`
+	Score    [2]uint        `json:"score"`
+	Sets     [2]uint        `json:"sets"`
+	Duration int64          `json:"duration"`
+	Players  []ResultPlayer `json:"players"`
+}
+
+type ResultMessage struct {
+	result ResultDescription
+}
+
+// NewResultMessage describes the result for the player of the index, -1 for a spectator.
+func NewResultMessage(result MatchResult, self int) ResultMessage {
+	viewer := engine.SIDE_NONE
+	if self >= 0 {
+		viewer = result.Players[self].Side
+	}
+
+	description := ResultDescription{
+		Reason:   result.Reason,
+		Winner:   RelativeSide(result.Winner, viewer),
+		Score:    [2]uint{result.ScoreA, result.ScoreB},
+		Sets:     [2]uint{result.SetsA, result.SetsB},
+		Duration: result.Duration.Milliseconds(),
+		Players:  make([]ResultPlayer, 0, len(result.Players)),
+	}
+
+	if viewer == engine.SIDE_B {
+		description.Score = [2]uint{result.ScoreB, result.ScoreA}
+		description.Sets = [2]uint{result.SetsB, result.SetsA}
+	}
+
+	for i, stats := range result.Players {
+		description.Players = append(description.Players, ResultPlayer{
+			Team:   RelativeSide(stats.Side, viewer),
+			Self:   i == self,
+			Goals:  stats.Goals,
+			Hits:   stats.Hits,
+			Pauses: stats.Pauses,
+		})
+	}
+
+	return ResultMessage{result: description}
+}
+
+func (message ResultMessage) Stringify() []byte {
+	result, err := json.Marshal(message.result)
+	if err != nil {
+		log.Println(err)
+
+		return []byte(RESULT + ":" + string(message.result.Reason))
+	}
+
+	return []byte(RESULT + ":" + string(result))
+}
+
+type WorldMallet struct {
+	position engine.Position
+	team     string
+}
+
+func NewWorldMallet(position engine.Position, team string) WorldMallet {
+	return WorldMallet{position: position, team: team}
+}
+
+type WorldPuck struct {
+	position engine.Position
+	spin     float64
+}
+
+func NewWorldPuck(position engine.Position, spin float64) WorldPuck {
+	return WorldPuck{position: position, spin: spin}
+}
+
+type WorldPowerUp struct {
+	powerUpType string
+	position    engine.Position
+}
+
+func NewWorldPowerUp(powerUpType string, position engine.Position) WorldPowerUp {
+	return WorldPowerUp{powerUpType: powerUpType, position: position}
+}
+
+type WorldEffect struct {
+	effectType string
+	side       string
+	timeLeft   float64
+}
+
+func NewWorldEffect(effect engine.Effect, viewer engine.Side) WorldEffect {
+	return WorldEffect{effectType: effect.Type, side: RelativeSide(effect.Side, viewer), timeLeft: effect.TimeLeft}
+}
+
+// WorldMatch is the match state as the viewer sees it, with their sets first.
+type WorldMatch struct {
+	clock        float64
+	set          uint
+	setsPlayer   uint
+	setsOpponent uint
+	overtime     bool
+}
+
+func NewWorldMatch(match engine.Match, viewer engine.Side) WorldMatch {
+	setsPlayer, setsOpponent := match.SetsA, match.SetsB
+	if viewer == engine.SIDE_B {
+		setsPlayer, setsOpponent = setsOpponent, setsPlayer
+	}
+
+	return WorldMatch{
+		clock:        match.Clock,
+		set:          match.Set,
+		setsPlayer:   setsPlayer,
+		setsOpponent: setsOpponent,
+		overtime:     match.Overtime,
+	}
+}
+
 type WorldMessage struct {
-	posAX    int
-	posAY    int
-	posBX    int
-	posBY    int
-	posPuckX int
-	posPuckY int
-	countA   uint
-	countB   uint
+	self           int
+	mallets        []WorldMallet
+	pucks          []WorldPuck
+	obstacles      []engine.Position
+	countA         uint
+	countB         uint
+	possessionSide string
+	possessionLeft float64
+	modifiersA     engine.Modifiers
+	modifiersB     engine.Modifiers
+	powerUps       []WorldPowerUp
+	effects        []WorldEffect
+	match          WorldMatch
+	phase          Phase
+	phaseLeft      time.Duration
 }
 
-func NewWorldMessage(posA, posB, puck Position, countA uint, countB uint) WorldMessage {
+// Self is the index of the receiver's own mallet, -1 for spectators.
+// Obstacles are the offsets of the obstacles from where the GAME message placed them.
+func NewWorldMessage(self int, mallets []WorldMallet, pucks []WorldPuck, obstacles []engine.Position, countA uint, countB uint, possessionSide string, possessionLeft float64,
+	modifiersA engine.Modifiers, modifiersB engine.Modifiers, powerUps []WorldPowerUp, effects []WorldEffect, match WorldMatch,
+	phase Phase, phaseLeft time.Duration) WorldMessage {
 	return WorldMessage{
-		posAX:    posA.x,
-		posAY:    posA.y,
-		posBX:    posB.x,
-		posBY:    posB.y,
-		posPuckX: puck.x,
-		posPuckY: puck.y,
-		countA:   countA,
-		countB:   countB,
+		self:           self,
+		mallets:        mallets,
+		pucks:          pucks,
+		obstacles:      obstacles,
+		countA:         countA,
+		countB:         countB,
+		possessionSide: possessionSide,
+		possessionLeft: possessionLeft,
+		modifiersA:     modifiersA,
+		modifiersB:     modifiersB,
+		powerUps:       powerUps,
+		effects:        effects,
+		match:          match,
+		phase:          phase,
+		phaseLeft:      phaseLeft,
 	}
 }
 
 func (message WorldMessage) Stringify() []byte {
-	return []byte(WORLD + ":" +
-		strconv.Itoa(message.posAX) + ":" + strconv.Itoa(message.posAY) + ":" +
-		strconv.Itoa(message.posBX) + ":" + strconv.Itoa(message.posBY) + ":" +
-		strconv.Itoa(message.posPuckX) + ":" + strconv.Itoa(message.posPuckY) + ":" +
-		strconv.Itoa(int(message.countA)) + ":" + strconv.Itoa(int(message.countB)))
+	var builder strings.Builder
+
+	builder.WriteString(WORLD + ":" + strconv.Itoa(message.self) + ":" + strconv.Itoa(len(message.mallets)))
+	for _, mallet := range message.mallets {
+		builder.WriteString(":" + strconv.Itoa(mallet.position.X) + ":" + strconv.Itoa(mallet.position.Y) + ":" + mallet.team)
+	}
+
+	builder.WriteString(":" + strconv.Itoa(int(message.countA)) + ":" + strconv.Itoa(int(message.countB)) + ":" +
+		message.possessionSide + ":" + strconv.Itoa(int(message.possessionLeft)) + ":" +
+		strconv.Itoa(len(message.pucks)))
+
+	for _, puck := range message.pucks {
+		builder.WriteString(":" + strconv.Itoa(puck.position.X) + ":" + strconv.Itoa(puck.position.Y) + ":" +
+			strconv.FormatFloat(puck.spin*1000, 'f', 2, 64))
+	}
+
+	builder.WriteString(":" + strconv.Itoa(len(message.obstacles)))
+	for _, offset := range message.obstacles {
+		builder.WriteString(":" + strconv.Itoa(offset.X) + ":" + strconv.Itoa(offset.Y))
+	}
+
+	builder.WriteString(":" + strconv.Itoa(message.modifiersA.MalletRadius) + ":" + strconv.Itoa(message.modifiersB.MalletRadius) + ":" +
+		strconv.FormatFloat(message.modifiersA.GoalScale, 'f', 2, 64) + ":" + strconv.FormatFloat(message.modifiersB.GoalScale, 'f', 2, 64))
+
+	builder.WriteString(":" + strconv.Itoa(len(message.powerUps)))
+	for _, powerUp := range message.powerUps {
+		builder.WriteString(":" + powerUp.powerUpType + ":" + strconv.Itoa(powerUp.position.X) + ":" + strconv.Itoa(powerUp.position.Y))
+	}
+
+	builder.WriteString(":" + strconv.Itoa(len(message.effects)))
+	for _, effect := range message.effects {
+		builder.WriteString(":" + effect.effectType + ":" + effect.side + ":" + strconv.Itoa(int(effect.timeLeft)))
+	}
+
+	overtime := "0"
+	if message.match.overtime {
+		overtime = "1"
+	}
+
+	builder.WriteString(":" + strconv.Itoa(int(message.match.clock)) + ":" + strconv.Itoa(int(message.match.set)) + ":" +
+		strconv.Itoa(int(message.match.setsPlayer)) + ":" + strconv.Itoa(int(message.match.setsOpponent)) + ":" + overtime)
+
+	builder.WriteString(":" + string(message.phase) + ":" + strconv.FormatInt(message.phaseLeft.Milliseconds(), 10))
+
+	return []byte(builder.String())
+}
+
+type EventMessage struct {
+	eventType engine.EventType
+	side      string
+	powerUp   string
+}
+
+// NewEventMessage describes the event for side's player, so the side is told relative to them.
+func NewEventMessage(event engine.Event, side engine.Side) EventMessage {
+	return EventMessage{
+		eventType: event.Type,
+		side:      RelativeSide(event.Side, side),
+		powerUp:   event.PowerUp,
+	}
+}
+
+// RelativeSide names the side for the viewer, spectators watch as SIDE_NONE and get the sides by name.
+func RelativeSide(side engine.Side, viewer engine.Side) string {
+	if side == engine.SIDE_NONE {
+		return EVENT_SIDE_NONE
+	}
+
+	if viewer == engine.SIDE_NONE {
+		if side == engine.SIDE_A {
+			return EVENT_SIDE_A
+		}
+
+		return EVENT_SIDE_B
+	}
+
+	if side == viewer {
+		return EVENT_SIDE_PLAYER
+	}
+
+	return EVENT_SIDE_OPPONENT
+}
+
+func (message EventMessage) Stringify() []byte {
+	if message.powerUp != "" {
+		return []byte(EVENT + ":" + string(message.eventType) + ":" + message.side + ":" + message.powerUp)
+	}
+
+	return []byte(EVENT + ":" + string(message.eventType) + ":" + message.side)
 }
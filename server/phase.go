@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"blindwizard.ru/hockey/engine"
+)
+
+type Phase string
+
+const PHASE_COUNTDOWN Phase = "COUNTDOWN"
+const PHASE_LIVE Phase = "LIVE"
+const PHASE_GOAL Phase = "GOAL"
+const PHASE_RESUME Phase = "RESUME"
+const PHASE_PAUSED Phase = "PAUSED"
+
+const EVENT_PAUSE engine.EventType = "PAUSE"
+const EVENT_PAUSE_DENIED engine.EventType = "PAUSE_DENIED"
+const EVENT_RESUME engine.EventType = "RESUME"
+
+// phaseDuration is how long the phase lasts, live play lasts until a goal.
+func (room *Room) phaseDuration(phase Phase) time.Duration {
+	rules := room.world.Rules
+
+	switch phase {
+	case PHASE_COUNTDOWN:
+		return time.Duration(rules.CountdownTime * float64(time.Millisecond))
+	case PHASE_GOAL:
+		return time.Duration(rules.GoalFreezeTime * float64(time.Millisecond))
+	case PHASE_RESUME:
+		return time.Duration(rules.ResumeTime * float64(time.Millisecond))
+	case PHASE_PAUSED:
+		return time.Duration(rules.PauseTime * float64(time.Millisecond))
+	case PHASE_RECONNECT:
+		return time.Duration(rules.ReconnectTime * float64(time.Millisecond))
+	default:
+		return 0
+	}
+}
+
+func nextPhase(phase Phase) Phase {
+	switch phase {
+	case PHASE_COUNTDOWN:
+		return PHASE_LIVE
+	case PHASE_GOAL, PHASE_PAUSED:
+		return PHASE_RESUME
+	default:
+		return PHASE_LIVE
+	}
+}
+
+// enterPhase switches the room to the phase, phases with no duration are skipped.
+func (room *Room) enterPhase(phase Phase) {
+	for phase != PHASE_LIVE && room.phaseDuration(phase) <= 0 {
+		phase = nextPhase(phase)
+	}
+
+	room.phase = phase
+	room.phaseLeft = room.phaseDuration(phase)
+}
+
+// stepPhase runs the countdown of a paused phase and moves on when it is over.
+func (room *Room) stepPhase(elapsed time.Duration) {
+	if room.phase == PHASE_LIVE {
+		return
+	}
+
+	room.phaseLeft -= elapsed
+	if room.phaseLeft > 0 {
+		return
+	}
+
+	if room.phase == PHASE_RECONNECT {
+		room.forfeit()
+
+		return
+	}
+
+	room.enterPhase(nextPhase(room.phase))
+}
+
+// pause stops live play when the player still has a pause left, otherwise only the player is told it was denied.
+func (room *Room) pause(index int) {
+	side := room.side(index)
+	if room.phase != PHASE_LIVE || room.pauses[index] == 0 {
+		room.players[index].Send(NewEventMessage(engine.Event{Type: EVENT_PAUSE_DENIED, Side: side}, side))
+
+		return
+	}
+
+	room.pauses[index]--
+
+	// Bots are always ready to go on, only the people at the rink decide when the pause is over.
+	for i, player := range room.players {
+		room.resumed[i] = player.bot
+	}
+
+	room.enterPhase(PHASE_PAUSED)
+	room.broadcastEvent(engine.Event{Type: EVENT_PAUSE, Side: side})
+}
+
+// resume counts the player in, play resumes after the countdown once every player agrees
+// or by itself when the pause runs out.
+func (room *Room) resume(index int) {
+	if room.phase != PHASE_PAUSED {
+		return
+	}
+
+	room.resumed[index] = true
+	room.broadcastEvent(engine.Event{Type: EVENT_RESUME, Side: room.side(index)})
+
+	if !slices.Contains(room.resumed, false) {
+		room.enterPhase(PHASE_RESUME)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"blindwizard.ru/hockey/engine"
+)
+
+func TestPhases(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	if room.phase != PHASE_COUNTDOWN {
+		t.Fatalf("room starts in %s", room.phase)
+	}
+
+	room.stepPhase(room.phaseDuration(PHASE_COUNTDOWN))
+	if room.phase != PHASE_LIVE {
+		t.Fatalf("phase %s after the countdown", room.phase)
+	}
+
+	room.enterPhase(PHASE_GOAL)
+	room.stepPhase(room.phaseDuration(PHASE_GOAL))
+	if room.phase != PHASE_RESUME {
+		t.Fatalf("phase %s after a goal", room.phase)
+	}
+
+	room.world.Rules.ResumeTime = 0
+	room.enterPhase(PHASE_GOAL)
+	room.stepPhase(room.phaseDuration(PHASE_GOAL))
+	if room.phase != PHASE_LIVE {
+		t.Fatalf("phase %s after a goal with no resume countdown", room.phase)
+	}
+}
+
+func TestPauseLimit(t *testing.T) {
+	room := testRoom(t, engine.RULES_ARCADE)
+	room.enterPhase(PHASE_LIVE)
+	player := room.players[0]
+
+	room.pause(0)
+	if room.phase != PHASE_PAUSED || room.pauses[0] != room.rules.PausesPerPlayer-1 {
+		t.Fatalf("phase %s with %d pauses left", room.phase, room.pauses[0])
+	}
+
+	room.resume(0)
+	if room.phase != PHASE_PAUSED {
+		t.Fatal("resumed before the opponent agreed")
+	}
+
+	room.resume(1)
+	room.stepPhase(room.phaseDuration(PHASE_RESUME))
+	if room.phase != PHASE_LIVE {
+		t.Fatalf("phase %s after both resumed", room.phase)
+	}
+
+	sent(player)
+	room.pause(0)
+	if room.phase != PHASE_LIVE {
+		t.Fatal("paused with no pauses left")
+	}
+
+	if !wasSent(player, NewEventMessage(engine.Event{Type: EVENT_PAUSE_DENIED, Side: engine.SIDE_A}, engine.SIDE_A)) {
+		t.Fatal("player not told the pause was denied")
+	}
+}
+
+func TestPauseRunsOut(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	room.enterPhase(PHASE_LIVE)
+
+	room.pause(1)
+	room.stepPhase(room.phaseDuration(PHASE_PAUSED))
+	if room.phase != PHASE_RESUME {
+		t.Fatalf("phase %s after the pause ran out", room.phase)
+	}
+}
+
+func TestPauseWithBot(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	room.enterPhase(PHASE_LIVE)
+	room.players[1].bot = true
+
+	room.pause(0)
+	room.resume(0)
+	if room.phase != PHASE_RESUME {
+		t.Fatalf("phase %s, the bot held the pause", room.phase)
+	}
+}
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// closed is closed when the player's connection is gone, a reconnect binds new channels.
+// spectatingRoomId is the room the player watches, spectators never send input to a room.
+// A queued player waits since queuedAt and plays a bot of botDifficulty when nobody comes, empty for never.
+// A bot's player is played by the server and never answers a pause.
 type Player struct {
-	id            uuid.UUID
-	connectionId  uuid.UUID
-	currentRoomId uuid.UUID
-	latency       int
-	inputChan     chan ClientMessage
-	writeChan     chan ServerMessage
+	id               uuid.UUID
+	connectionId     uuid.UUID
+	currentRoomId    uuid.UUID
+	spectatingRoomId uuid.UUID
+	queuedAt         time.Time
+	botDifficulty    string
+	bot              bool
+	latency          int
+	inputChan        chan ClientMessage
+	writeChan        chan ServerMessage
+	closed           chan struct{}
+	mutex            sync.Mutex
 }
 
 func NewPlayer(id uuid.UUID, connectionId uuid.UUID) *Player {
@@ -27,9 +38,86 @@ func (player *Player) SetCurrentRoomId(id uuid.UUID) {
 	player.currentRoomId = id
 }
 
+// SetExchange binds the player to a connection, a reconnecting player keeps its input channel
+// so the room it plays in reads the new connection without noticing.
 func (player *Player) SetExchange(read chan ClientMessage, write chan ServerMessage) {
-	player.inputChan = read
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if player.inputChan == nil {
+		player.inputChan = read
+	}
+
 	player.writeChan = write
+	player.closed = make(chan struct{})
+}
+
+func (player *Player) Disconnect() {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	if player.closed == nil {
+		return
+	}
+
+	select {
+	case <-player.closed:
+	default:
+		close(player.closed)
+	}
+}
+
+func (player *Player) Connected() bool {
+	closed := player.Closed()
+	if closed == nil {
+		return false
+	}
+
+	select {
+	case <-closed:
+		return false
+	default:
+		return true
+	}
+}
+
+func (player *Player) Closed() chan struct{} {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
+	return player.closed
+}
+
+// Send writes the message to the player's connection, it is dropped when the player is disconnected.
+func (player *Player) Send(message ServerMessage) {
+	player.mutex.Lock()
+	write, closed := player.writeChan, player.closed
+	player.mutex.Unlock()
+
+	if write == nil {
+		return
+	}
+
+	select {
+	case write <- message:
+	case <-closed:
+	}
+}
+
+// TrySend is Send for those who must not wait, the message is dropped when the connection is busy.
+func (player *Player) TrySend(message ServerMessage) {
+	player.mutex.Lock()
+	write := player.writeChan
+	player.mutex.Unlock()
+
+	if write == nil {
+		return
+	}
+
+	select {
+	case write <- message:
+	default:
+	}
 }
 
 func (player *Player) CalcLatency(ping PongMessage) {
@@ -38,9 +126,15 @@ func (player *Player) CalcLatency(ping PongMessage) {
 }
 
 func (player *Player) GetInput() chan ClientMessage {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
 	return player.inputChan
 }
 
 func (player *Player) GetWrite() chan ServerMessage {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+
 	return player.writeChan
 }
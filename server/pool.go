@@ -1,37 +1,73 @@
 package main
 
 import (
-	"errors"
 	"log"
 	"math/rand"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
 
+	"blindwizard.ru/hockey/engine"
 	"github.com/google/uuid"
 )
 
+// The pool is shared by the connections' readers, matchmaking and the rooms' own loops.
+// mutex guards the connections, players, queues and rooms and is never held while anyone is sent a message,
+// spectators are read on every broadcast, so they are guarded separately.
+// Matches are only started by matchmaking, others ask it through botRequests.
 type Pool struct {
-	connections map[uuid.UUID]*Connection
-	players     map[uuid.UUID]*Player
-	queue       []*Player
-	rooms       map[uuid.UUID]*Room
+	connections     map[uuid.UUID]*Connection
+	players         map[uuid.UUID]*Player
+	queues          map[string][]*Player
+	rooms           map[uuid.UUID]*Room
+	mutex           sync.Mutex
+	spectators      map[uuid.UUID][]*Player
+	spectatorsMutex sync.RWMutex
+	botRequests     chan botRequest
+	resultHooks     []func(MatchResult)
 }
 
 func NewPool() *Pool {
+	queues := make(map[string][]*Player)
+	for _, name := range engine.RULES_PRESETS {
+		queues[name] = make([]*Player, 0, 100)
+	}
+
 	return &Pool{
-		make(map[uuid.UUID]*Connection),
-		make(map[uuid.UUID]*Player),
-		make([]*Player, 0, 100),
-		make(map[uuid.UUID]*Room),
+		connections: make(map[uuid.UUID]*Connection),
+		players:     make(map[uuid.UUID]*Player),
+		queues:      queues,
+		rooms:       make(map[uuid.UUID]*Room),
+		spectators:  make(map[uuid.UUID][]*Player),
+		botRequests: make(chan botRequest, BOT_REQUEST_BUFFER),
 	}
 }
 
+// NewPlayer binds a player whose match is still on to the new connection, anyone else gets a fresh player.
+// A player often comes back before the old connection is noticed to be gone, so that one is closed first.
 func (pool *Pool) NewPlayer(playerId uuid.UUID, conn *Connection) *Player {
 	if playerId == uuid.Nil {
 		playerId = uuid.New()
 	}
 
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if player, exists := pool.players[playerId]; exists && pool.rooms[player.currentRoomId] != nil {
+		old, exists := pool.connections[player.connectionId]
+		player.Disconnect()
+		player.connectionId = conn.id
+		pool.connections[conn.id] = conn
+
+		if exists {
+			delete(pool.connections, old.id)
+			old.conn.Close()
+		}
+
+		return player
+	}
+
 	player := NewPlayer(playerId, conn.id)
 	pool.connections[conn.id] = conn
 	pool.players[player.id] = player
@@ -39,24 +75,21 @@ func (pool *Pool) NewPlayer(playerId uuid.UUID, conn *Connection) *Player {
 	return player
 }
 
-func (pool *Pool) CreateRoom(playerA *Player, playerB *Player) *Room {
-	var room *Room
-	if rand.Intn(2) == 1 {
-		room = NewRoom(
-			playerA,
-			playerB,
-		)
-	} else {
-		room = NewRoom(
-			playerB,
-			playerA,
-		)
-	}
+// CreateRoom makes up the teams at random, the first half of the players plays side A.
+func (pool *Pool) CreateRoom(players []*Player, rules engine.GameRules) *Room {
+	rand.Shuffle(len(players), func(i, j int) {
+		players[i], players[j] = players[j], players[i]
+	})
+
+	room := NewRoom(players, rinks[rules.Rink], rules)
 
-	playerA.currentRoomId = room.id
-	playerB.currentRoomId = room.id
+	pool.mutex.Lock()
+	for _, player := range players {
+		player.currentRoomId = room.id
+	}
 
 	pool.rooms[room.id] = room
+	pool.mutex.Unlock()
 
 	log.Println("Start game: " + room.id.String())
 
@@ -64,6 +97,9 @@ func (pool *Pool) CreateRoom(playerA *Player, playerB *Player) *Room {
 }
 
 func (pool *Pool) RemoveConnection(connId uuid.UUID) *Player {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
 	delete(pool.connections, connId)
 	for _, player := range pool.players {
 		if connId == player.connectionId {
@@ -74,90 +110,220 @@ func (pool *Pool) RemoveConnection(connId uuid.UUID) *Player {
 	return nil
 }
 
+// RemovePlayer forgets the player, the match the player was in is lost.
 func (pool *Pool) RemovePlayer(id uuid.UUID) {
+	pool.mutex.Lock()
 	player, exists := pool.players[id]
 	if !exists {
+		pool.mutex.Unlock()
+
 		return
 	}
 
 	delete(pool.players, id)
 	delete(pool.connections, player.connectionId)
+	pool.unQueuePlayer(player)
+	roomId := player.currentRoomId
+	pool.mutex.Unlock()
 
-	pool.UnQueuePlayer(player)
-	if player.currentRoomId != uuid.Nil {
-		pool.DeleteRoom(player.currentRoomId, errors.New("player disconnected"))
+	pool.RemoveSpectator(player)
+	if roomId != uuid.Nil {
+		pool.DeleteRoom(roomId, NewMatchEnd(END_DISCONNECT, player, "player disconnected"))
 	}
 }
 
-func (pool *Pool) DeleteRoom(roomId uuid.UUID, reason error) {
+// AwaitReconnect tells the player's room that the player is gone for now,
+// it reports false when there is no match to come back to.
+func (pool *Pool) AwaitReconnect(player *Player) bool {
+	pool.mutex.Lock()
+	room, exists := pool.rooms[player.currentRoomId]
+	if !exists || room.rules.ReconnectTime <= 0 {
+		pool.mutex.Unlock()
+
+		return false
+	}
+
+	pool.unQueuePlayer(player)
+	pool.mutex.Unlock()
+
+	room.SetPresence(player, false)
+
+	return true
+}
+
+func (pool *Pool) ReconnectPlayer(player *Player) {
+	pool.mutex.Lock()
+	room, exists := pool.rooms[player.currentRoomId]
+	pool.mutex.Unlock()
+
+	if exists {
+		log.Println("Player reconnected: " + player.id.String())
+		room.SetPresence(player, true)
+	}
+}
+
+// DeleteRoom takes the room out of the pool, the room itself sends everyone the result on its way out.
+func (pool *Pool) DeleteRoom(roomId uuid.UUID, end MatchEnd) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.deleteRoom(roomId, end)
+}
+
+func (pool *Pool) deleteRoom(roomId uuid.UUID, end MatchEnd) {
 	room, exists := pool.rooms[roomId]
 	if !exists {
 		return
 	}
 
-	go room.Close(reason)
+	go room.Close(end)
 
-	for _, player := range []*Player{room.playerA, room.playerB} {
-		player.GetWrite() <- NewExitGameMessage(reason.Error())
+	for _, player := range room.players {
+		player.currentRoomId = uuid.Nil
 	}
 
 	delete(pool.rooms, roomId)
 }
 
-func (pool *Pool) QueuePlayer(player *Player) {
-	for _, queuedPlayer := range pool.queue {
-		if queuedPlayer.id == player.id {
-			return
+// EndRoom stops the match on the server's own account, nobody wins it.
+func (pool *Pool) EndRoom(roomId uuid.UUID, message string) {
+	pool.DeleteRoom(roomId, NewMatchEnd(END_ADMIN, nil, message))
+}
+
+// EndRooms ends every match there is at once, as EndRoom does.
+func (pool *Pool) EndRooms(message string) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for roomId := range pool.rooms {
+		pool.deleteRoom(roomId, NewMatchEnd(END_ADMIN, nil, message))
+	}
+}
+
+func (pool *Pool) QueuePlayer(player *Player, rules string, bot string) {
+	pool.RemoveSpectator(player)
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if _, exists := pool.queues[rules]; !exists {
+		rules = engine.DEFAULT_RULES
+	}
+
+	for _, queue := range pool.queues {
+		for _, queuedPlayer := range queue {
+			if queuedPlayer.id == player.id {
+				return
+			}
 		}
 	}
 
-	pool.queue = append(pool.queue, player)
+	player.queuedAt = time.Now()
+	player.botDifficulty = bot
+	pool.queues[rules] = append(pool.queues[rules], player)
 
-	log.Println("Current queue: " + strconv.Itoa(len(pool.queue)))
+	log.Println("Current queue " + rules + ": " + strconv.Itoa(len(pool.queues[rules])))
 }
 
 func (pool *Pool) UnQueuePlayer(player *Player) {
-	pool.queue = slices.DeleteFunc(pool.queue, func(other *Player) bool {
-		return player.id == other.id
-	})
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
 
-	log.Println("Current queue: " + strconv.Itoa(len(pool.queue)))
+	pool.unQueuePlayer(player)
 }
 
-func (pool *Pool) UpdateOnline() {
-	log.Println("Current online: " + strconv.Itoa(len(pool.players)))
+func (pool *Pool) unQueuePlayer(player *Player) {
+	for rules, queue := range pool.queues {
+		pool.queues[rules] = slices.DeleteFunc(queue, func(other *Player) bool {
+			return player.id == other.id
+		})
+	}
+
+	log.Println("Current queue: " + strconv.Itoa(pool.queueLength()))
+}
 
+func (pool *Pool) queueLength() int {
+	length := 0
+	for _, queue := range pool.queues {
+		length += len(queue)
+	}
+
+	return length
+}
+
+func (pool *Pool) UpdateOnline() {
+	pool.mutex.Lock()
+	players := make([]*Player, 0, len(pool.players))
 	for _, player := range pool.players {
-		player.GetWrite() <- NewOnlineMessage(len(pool.players))
+		players = append(players, player)
+	}
+	pool.mutex.Unlock()
+
+	log.Println("Current online: " + strconv.Itoa(len(players)))
+
+	for _, player := range players {
+		player.Send(NewOnlineMessage(len(players)))
 	}
 }
 
+// CurrentRoomId is the room the player plays in, uuid.Nil when the player is not in a match.
+func (pool *Pool) CurrentRoomId(player *Player) uuid.UUID {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	return player.currentRoomId
+}
+
 func (pool *Pool) Matchmaking() {
+	ticker := time.NewTicker(time.Second)
+
 	for {
-		for {
-			if cap(pool.queue) < 2 || len(pool.queue) < 2 {
-				break
+		select {
+		case request := <-pool.botRequests:
+			pool.startRequestedBotMatch(request)
+		case <-ticker.C:
+			for _, rules := range engine.RULES_PRESETS {
+				pool.matchQueue(rules)
+				pool.backfillQueue(rules)
 			}
+		}
+	}
+}
 
-			playersFromQueue := pool.queue[0:2]
-
-			if playersFromQueue[0] == nil || playersFromQueue[1] == nil {
-				break
-			}
+// matchQueue starts a match whenever the queue has enough players to fill both teams.
+func (pool *Pool) matchQueue(rules string) {
+	size := 2 * rulesPresets[rules].TeamSize
 
-			pool.queue = pool.queue[2:]
+	for {
+		pool.mutex.Lock()
+		queue := pool.queues[rules]
+		if len(queue) < size || slices.Contains(queue[0:size], nil) {
+			pool.mutex.Unlock()
 
-			room := pool.CreateRoom(playersFromQueue[0], playersFromQueue[1])
-			for _, player := range playersFromQueue {
-				player.writeChan <- NewGameMessage(room.id)
-			}
+			break
+		}
 
-			log.Println("Current queue: " + strconv.Itoa(len(pool.queue)))
-			log.Println("Current games: " + strconv.Itoa(len(pool.rooms)))
+		playersFromQueue := slices.Clone(queue[0:size])
+		pool.queues[rules] = queue[size:]
+		length := len(pool.queues[rules])
+		pool.mutex.Unlock()
 
-			go room.RunGame()
-		}
+		log.Println("Current queue " + rules + ": " + strconv.Itoa(length))
+		pool.startRoom(pool.CreateRoom(playersFromQueue, rulesPresets[rules]))
+	}
+}
 
-		time.Sleep(time.Second)
+// startRoom tells every player their seat and starts the match.
+func (pool *Pool) startRoom(room *Room) {
+	for i, player := range room.players {
+		player.Send(NewGameMessage(room.id, room.PlayerSettings(i)))
 	}
+
+	pool.mutex.Lock()
+	games := len(pool.rooms)
+	pool.mutex.Unlock()
+
+	log.Println("Current games: " + strconv.Itoa(games))
+
+	go room.RunGame()
 }
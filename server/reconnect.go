@@ -0,0 +1,72 @@
+package main
+
+import (
+	"slices"
+
+	"blindwizard.ru/hockey/engine"
+)
+
+const PHASE_RECONNECT Phase = "RECONNECT"
+
+const EVENT_DISCONNECTED engine.EventType = "DISCONNECTED"
+const EVENT_RECONNECTED engine.EventType = "RECONNECTED"
+
+const MAX_PRESENCE_CHANGES = 4
+
+type presenceChange struct {
+	player    *Player
+	connected bool
+}
+
+// SetPresence tells the room a player dropped or came back, the room picks it up in its own loop.
+// It waits for the room to take the change, unless the room has already finished.
+func (room *Room) SetPresence(player *Player, connected bool) {
+	select {
+	case room.presence <- presenceChange{player, connected}:
+	case <-room.done:
+	}
+}
+
+// handlePresence holds play while anyone is away and resumes it with a countdown once everyone is back.
+func (room *Room) handlePresence(change presenceChange) {
+	index := slices.Index(room.players, change.player)
+	if index < 0 {
+		return
+	}
+
+	side := room.side(index)
+	wasAway := room.away[index]
+	room.away[index] = !change.connected
+
+	if !change.connected {
+		room.broadcastEvent(engine.Event{Type: EVENT_DISCONNECTED, Side: side})
+		if room.phase != PHASE_RECONNECT {
+			room.enterPhase(PHASE_RECONNECT)
+		}
+
+		return
+	}
+
+	// A player back before the drop was noticed only needs the game again.
+	change.player.Send(NewGameMessage(room.id, room.PlayerSettings(index)))
+	if !wasAway {
+		return
+	}
+
+	room.broadcastEvent(engine.Event{Type: EVENT_RECONNECTED, Side: side})
+
+	if room.phase == PHASE_RECONNECT && !slices.Contains(room.away, true) {
+		room.enterPhase(PHASE_RESUME)
+	}
+}
+
+// forfeit ends the match when the grace period is over and drops the players that did not come back.
+func (room *Room) forfeit() {
+	pool.DeleteRoom(room.id, NewMatchEnd(END_TIMEOUT, nil, "opponent did not reconnect"))
+
+	for i, player := range room.players {
+		if room.away[i] {
+			pool.RemovePlayer(player.id)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"blindwizard.ru/hockey/engine"
+	"github.com/google/uuid"
+)
+
+func TestReconnectInTime(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	room.enterPhase(PHASE_LIVE)
+	player, opponent := room.players[0], room.players[1]
+
+	room.handlePresence(presenceChange{opponent, false})
+	if room.phase != PHASE_RECONNECT || !room.away[1] {
+		t.Fatalf("phase %s, away %v after a drop", room.phase, room.away)
+	}
+
+	if !wasSent(player, NewEventMessage(engine.Event{Type: EVENT_DISCONNECTED, Side: engine.SIDE_B}, engine.SIDE_A)) {
+		t.Fatal("player not told the opponent dropped")
+	}
+
+	room.stepPhase(room.phaseDuration(PHASE_RECONNECT) / 2)
+	room.handlePresence(presenceChange{opponent, true})
+	if room.phase != PHASE_RESUME || room.away[1] {
+		t.Fatalf("phase %s, away %v after coming back", room.phase, room.away)
+	}
+
+	if !wasSent(opponent, NewGameMessage(room.id, room.PlayerSettings(1))) {
+		t.Fatal("game not sent again to the player who came back")
+	}
+}
+
+func TestReconnectTimeout(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	room.enterPhase(PHASE_LIVE)
+	player, opponent := room.players[0], room.players[1]
+
+	room.handlePresence(presenceChange{opponent, false})
+	room.stepPhase(room.phaseDuration(PHASE_RECONNECT) - time.Millisecond)
+	if room.phase != PHASE_RECONNECT {
+		t.Fatalf("phase %s before the grace period is over", room.phase)
+	}
+
+	room.stepPhase(time.Millisecond)
+
+	pool.mutex.Lock()
+	_, playing := pool.rooms[room.id]
+	_, staying := pool.players[player.id]
+	_, dropped := pool.players[opponent.id]
+	pool.mutex.Unlock()
+
+	if playing || !staying || dropped || pool.CurrentRoomId(player) != uuid.Nil {
+		t.Fatalf("room kept %v, player kept %v, opponent kept %v", playing, staying, !dropped)
+	}
+
+	// Nothing runs the room's loop, the end it was closed with waits in its exit channel.
+	select {
+	case end := <-room.exit:
+		if result := room.result(end); result.Reason != END_TIMEOUT || result.Winner != engine.SIDE_A {
+			t.Fatalf("%s won by %v, want a timeout won by A", result.Reason, result.Winner)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("room not closed after the grace period")
+	}
+}
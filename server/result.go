@@ -0,0 +1,148 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"blindwizard.ru/hockey/engine"
+	"github.com/google/uuid"
+)
+
+type EndReason string
+
+const END_COMPLETED EndReason = "completed"
+const END_FORFEIT EndReason = "forfeit"
+const END_DISCONNECT EndReason = "disconnect"
+const END_TIMEOUT EndReason = "timeout"
+const END_ADMIN EndReason = "admin"
+
+// MatchEnd is why a room closes, Player is the one who ended the match, nil when nobody did,
+// and Message what the players are told on exit.
+type MatchEnd struct {
+	Reason  EndReason
+	Player  *Player
+	Message string
+}
+
+func NewMatchEnd(reason EndReason, player *Player, message string) MatchEnd {
+	return MatchEnd{
+		Reason:  reason,
+		Player:  player,
+		Message: message,
+	}
+}
+
+// PlayerStats is what a player did in the match, a goal counts for the mallet that last hit the puck in.
+type PlayerStats struct {
+	Id     uuid.UUID
+	Side   engine.Side
+	Goals  int
+	Hits   int
+	Pauses int
+}
+
+// MatchResult is how a match ended, Winner is SIDE_NONE when nobody won it.
+// The score is the last set's and Duration the time the world ran, without pauses and countdowns.
+type MatchResult struct {
+	RoomId   uuid.UUID
+	Rules    string
+	Reason   EndReason
+	Winner   engine.Side
+	ScoreA   uint
+	ScoreB   uint
+	SetsA    uint
+	SetsB    uint
+	Duration time.Duration
+	Players  []PlayerStats
+}
+
+// recordEvent keeps the players' stats up to date with what happened in the world.
+func (room *Room) recordEvent(event engine.Event) {
+	switch event.Type {
+	case engine.EVENT_MALLET_HIT:
+		room.stats[event.Mallet].Hits++
+		room.lastHit[event.Puck] = event.Mallet
+	case engine.EVENT_GOAL:
+		if hitter := room.lastHit[event.Puck]; hitter >= 0 && room.side(hitter) == event.Side {
+			room.stats[hitter].Goals++
+		}
+
+		room.lastHit[event.Puck] = -1
+	case engine.EVENT_SERVE, engine.EVENT_STUCK_PUCK:
+		room.lastHit[event.Puck] = -1
+	}
+}
+
+// result sums the match up, a player who walks out or drops loses it for the team
+// and so do the players who did not come back in time.
+func (room *Room) result(end MatchEnd) MatchResult {
+	winner := room.world.Match.Winner
+	switch end.Reason {
+	case END_FORFEIT, END_DISCONNECT:
+		winner = engine.SIDE_NONE
+		if index := slices.Index(room.players, end.Player); index >= 0 {
+			winner = room.side(index).Opponent()
+		}
+	case END_TIMEOUT:
+		winner = engine.SIDE_NONE
+		for i, away := range room.away {
+			if !away {
+				continue
+			}
+
+			if winner == room.side(i) {
+				winner = engine.SIDE_NONE
+
+				break
+			}
+
+			winner = room.side(i).Opponent()
+		}
+	case END_ADMIN:
+		winner = engine.SIDE_NONE
+	}
+
+	players := slices.Clone(room.stats)
+	for i := range players {
+		players[i].Pauses = room.world.Rules.PausesPerPlayer - room.pauses[i]
+	}
+
+	return MatchResult{
+		RoomId:   room.id,
+		Rules:    room.world.Rules.Name,
+		Reason:   end.Reason,
+		Winner:   winner,
+		ScoreA:   room.world.ScoreA,
+		ScoreB:   room.world.ScoreB,
+		SetsA:    room.world.Match.SetsA,
+		SetsB:    room.world.Match.SetsB,
+		Duration: time.Duration(room.world.Time * float64(time.Millisecond)),
+		Players:  players,
+	}
+}
+
+// finish tells the players and spectators how the match ended and sends them off,
+// then hands the result to the rest of the server.
+func (room *Room) finish(end MatchEnd) {
+	result := room.result(end)
+
+	for i, player := range room.players {
+		player.Send(NewResultMessage(result, i))
+		player.Send(NewExitGameMessage(end.Message))
+	}
+
+	pool.closeSpectators(room.id, result, end.Message)
+	pool.reportResult(result)
+}
+
+// OnMatchResult adds a hook called with the result of every match, from the room's own loop.
+// Hooks are meant to be added before matchmaking starts.
+func (pool *Pool) OnMatchResult(hook func(MatchResult)) {
+	pool.resultHooks = append(pool.resultHooks, hook)
+}
+
+func (pool *Pool) reportResult(result MatchResult) {
+	for _, hook := range pool.resultHooks {
+		hook(result)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"blindwizard.ru/hockey/engine"
+)
+
+func TestResultWinner(t *testing.T) {
+	cases := []struct {
+		name    string
+		preset  string
+		reason  EndReason
+		player  int
+		winner  engine.Side
+		away    []int
+		outcome engine.Side
+	}{
+		{"completed", engine.RULES_RANKED, END_COMPLETED, -1, engine.SIDE_B, nil, engine.SIDE_B},
+		{"completed as a draw", engine.RULES_RANKED, END_COMPLETED, -1, engine.SIDE_NONE, nil, engine.SIDE_NONE},
+		{"forfeit by A", engine.RULES_RANKED, END_FORFEIT, 0, engine.SIDE_A, nil, engine.SIDE_B},
+		{"disconnect by B", engine.RULES_RANKED, END_DISCONNECT, 1, engine.SIDE_B, nil, engine.SIDE_A},
+		{"forfeit by nobody", engine.RULES_RANKED, END_FORFEIT, -1, engine.SIDE_A, nil, engine.SIDE_NONE},
+		{"timeout of B", engine.RULES_RANKED, END_TIMEOUT, -1, engine.SIDE_B, []int{1}, engine.SIDE_A},
+		{"timeout of both", engine.RULES_RANKED, END_TIMEOUT, -1, engine.SIDE_A, []int{0, 1}, engine.SIDE_NONE},
+		{"timeout of a whole team", engine.RULES_DOUBLES, END_TIMEOUT, -1, engine.SIDE_NONE, []int{2, 3}, engine.SIDE_A},
+		{"timeout of one of each team", engine.RULES_DOUBLES, END_TIMEOUT, -1, engine.SIDE_NONE, []int{1, 2}, engine.SIDE_NONE},
+		{"ended by the server", engine.RULES_RANKED, END_ADMIN, -1, engine.SIDE_A, nil, engine.SIDE_NONE},
+	}
+
+	for _, test := range cases {
+		room := testRoom(t, test.preset)
+		room.world.Match.Winner = test.winner
+		for _, index := range test.away {
+			room.away[index] = true
+		}
+
+		var player *Player
+		if test.player >= 0 {
+			player = room.players[test.player]
+		}
+
+		result := room.result(NewMatchEnd(test.reason, player, test.name))
+		if result.Winner != test.outcome || result.Reason != test.reason {
+			t.Errorf("%s: %s won by %v, want %v", test.name, result.Reason, result.Winner, test.outcome)
+		}
+	}
+}
+
+func TestResultStats(t *testing.T) {
+	room := testRoom(t, engine.RULES_CRAZY)
+	room.world.ScoreA, room.world.ScoreB = 4, 2
+	room.world.Match.SetsA, room.world.Match.SetsB = 1, 1
+	room.pauses[1]--
+
+	for _, event := range []engine.Event{
+		{Type: engine.EVENT_MALLET_HIT, Mallet: 0},
+		{Type: engine.EVENT_GOAL, Side: engine.SIDE_A},
+		{Type: engine.EVENT_MALLET_HIT, Mallet: 1},
+		{Type: engine.EVENT_MALLET_HIT, Mallet: 0},
+		{Type: engine.EVENT_GOAL, Side: engine.SIDE_B},
+	} {
+		room.recordEvent(event)
+	}
+
+	result := room.result(NewMatchEnd(END_ADMIN, nil, "test"))
+	if result.ScoreA != 4 || result.ScoreB != 2 || result.SetsA != 1 || result.SetsB != 1 {
+		t.Fatalf("score %d:%d in sets %d:%d, want 4:2 in 1:1", result.ScoreA, result.ScoreB, result.SetsA, result.SetsB)
+	}
+
+	// B's goal was last touched by A's mallet, so no player is credited with it.
+	a, b := result.Players[0], result.Players[1]
+	if a.Goals != 1 || a.Hits != 2 || a.Pauses != 0 || b.Goals != 0 || b.Hits != 1 || b.Pauses != 1 {
+		t.Fatalf("stats %+v and %+v", a, b)
+	}
+}
+
+func TestResultMessage(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	room.world.ScoreA, room.world.ScoreB = 3, 1
+	room.world.Match.SetsA, room.world.Match.SetsB = 2, 0
+	room.world.Match.Winner = engine.SIDE_A
+	room.stats[0].Goals = 3
+
+	result := room.result(NewMatchEnd(END_COMPLETED, nil, "game is finished"))
+
+	cases := []struct {
+		name   string
+		self   int
+		winner string
+		score  [2]uint
+		sets   [2]uint
+		teams  [2]string
+	}{
+		{"side A", 0, EVENT_SIDE_PLAYER, [2]uint{3, 1}, [2]uint{2, 0}, [2]string{EVENT_SIDE_PLAYER, EVENT_SIDE_OPPONENT}},
+		{"side B", 1, EVENT_SIDE_OPPONENT, [2]uint{1, 3}, [2]uint{0, 2}, [2]string{EVENT_SIDE_OPPONENT, EVENT_SIDE_PLAYER}},
+		{"spectator", -1, EVENT_SIDE_A, [2]uint{3, 1}, [2]uint{2, 0}, [2]string{EVENT_SIDE_A, EVENT_SIDE_B}},
+	}
+
+	for _, test := range cases {
+		description := NewResultMessage(result, test.self).result
+		if description.Winner != test.winner || description.Score != test.score || description.Sets != test.sets {
+			t.Errorf("%s: won by %s at %v in sets %v", test.name, description.Winner, description.Score, description.Sets)
+		}
+
+		for i, player := range description.Players {
+			if player.Team != test.teams[i] || player.Self != (i == test.self) {
+				t.Errorf("%s: player %d is %s, self %v", test.name, i, player.Team, player.Self)
+			}
+		}
+
+		if description.Players[0].Goals != 3 {
+			t.Errorf("%s: goals of A's player %d, want 3", test.name, description.Players[0].Goals)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"os"
+
+	"blindwizard.ru/hockey/engine"
+)
+
+const RINKS_ENV = "HOCKEY_RINKS"
+
+//go:embed rinks/*.json
+var bundledRinks embed.FS
+
+func LoadRinks() (map[string]*engine.Rink, error) {
+	rinks := make(map[string]*engine.Rink)
+
+	if err := engine.LoadRinksFrom(bundledRinks, "rinks", rinks); err != nil {
+		return nil, err
+	}
+
+	if dir := os.Getenv(RINKS_ENV); dir != "" {
+		if err := engine.LoadRinksFrom(os.DirFS(dir), ".", rinks); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, exists := rinks[engine.DEFAULT_RINK]; !exists {
+		return nil, errors.New("default rink " + engine.DEFAULT_RINK + " is not defined")
+	}
+
+	return rinks, nil
+}
+
+func DescribeRink(rink *engine.Rink, obstacles []engine.Obstacle, flip bool) RinkDescription {
+	transform := func(point engine.Vector) engine.Vector {
+		if flip {
+			return rink.FlipVector(point)
+		}
+
+		return point
+	}
+
+	playerGoal, opponentGoal := rink.GoalA, rink.GoalB
+	playerZone, opponentZone := rink.MalletA, rink.MalletB
+	if flip {
+		playerGoal, opponentGoal = rink.FlipZone(rink.GoalB), rink.FlipZone(rink.GoalA)
+		playerZone, opponentZone = rink.FlipZone(rink.MalletB), rink.FlipZone(rink.MalletA)
+	}
+
+	description := RinkDescription{
+		Name:         rink.Name,
+		Width:        rink.Width,
+		Height:       rink.Height,
+		Faceoff:      describePoint(transform(rink.Faceoff)),
+		Walls:        make([][4]float64, 0, len(rink.Walls)),
+		Posts:        make([][3]float64, 0, len(rink.Posts)),
+		Obstacles:    make([]ObstacleDescription, 0, len(obstacles)),
+		PlayerGoal:   describeZone(playerGoal),
+		OpponentGoal: describeZone(opponentGoal),
+		PlayerZone:   describeZone(playerZone),
+		OpponentZone: describeZone(opponentZone),
+	}
+
+	for _, wall := range rink.Walls {
+		start, end := transform(wall.Start), transform(wall.End)
+		description.Walls = append(description.Walls, [4]float64{start.X, start.Y, end.X, end.Y})
+	}
+
+	for _, post := range rink.Posts {
+		center := transform(post.Center)
+		description.Posts = append(description.Posts, [3]float64{center.X, center.Y, post.Radius})
+	}
+
+	for _, obstacle := range obstacles {
+		description.Obstacles = append(description.Obstacles, ObstacleDescription{
+			Type:   obstacle.Type,
+			Center: describePoint(transform(obstacle.Center)),
+			Radius: obstacle.Radius,
+			From:   describePoint(transform(obstacle.Start)),
+			To:     describePoint(transform(obstacle.End)),
+		})
+	}
+
+	return description
+}
+
+func describePoint(point engine.Vector) [2]float64 {
+	return [2]float64{point.X, point.Y}
+}
+
+func describeZone(zone engine.Zone) [4]float64 {
+	return [4]float64{zone.Min.X, zone.Min.Y, zone.Max.X, zone.Max.Y}
+}
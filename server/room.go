@@ -1,296 +1,403 @@
 package main
 
 import (
-	"errors"
 	"log"
-	"math"
 	"time"
 
+	"blindwizard.ru/hockey/engine"
 	"github.com/google/uuid"
 )
 
-type Room struct {
-	id      uuid.UUID
-	playerA *Player
-	playerB *Player
-	world   *World
-	exit    chan error
-}
-
-func NewRoom(playerA *Player, playerB *Player) *Room {
-	return &Room{
-		uuid.New(),
-		playerA,
-		playerB,
-		NewWorld(),
-		make(chan error),
-	}
-}
+const MAX_PHYSICS_STEPS = 20
 
-func (room *Room) Close(reason error) {
-	log.Println("Close game: " + room.id.String() + " - " + reason.Error())
-	room.exit <- reason
+// Players play the world's mallets of the same index, side A's team first.
+// The world only runs in the live phase, phaseLeft is what remains of any other.
+// LastHit is the player whose mallet last hit each puck, -1 for none.
+// Rules are the world's and spectatorSettings never change, the pool reads them outside the room's loop.
+type Room struct {
+	id                uuid.UUID
+	players           []*Player
+	rules             engine.GameRules
+	spectatorSettings GameSettings
+	world             engine.World
+	inputs            engine.Inputs
+	events            []engine.Event
+	phase             Phase
+	phaseLeft         time.Duration
+	pauses            []int
+	resumed           []bool
+	away              []bool
+	stats             []PlayerStats
+	lastHit           [engine.MAX_PUCKS]int
+	presence          chan presenceChange
+	exit              chan MatchEnd
+	done              chan struct{}
 }
 
-func (room *Room) RunGame() {
-	updateTicker := time.NewTicker(time.Duration(PHYSICS_CYCLE) * time.Millisecond)
-	broadcastTicker := time.NewTicker(time.Duration(NETWORK_CYCLE) * time.Millisecond)
+func NewRoom(players []*Player, rink *engine.Rink, rules engine.GameRules) *Room {
+	world := engine.NewWorld(rink, rules)
+	world.Seed(uint64(time.Now().UnixNano()))
 
-	timerA := time.Now()
-	timerB := time.Now()
-	timerWorld := time.Now()
+	pauses := make([]int, len(players))
+	for i := range pauses {
+		pauses[i] = rules.PausesPerPlayer
+	}
 
-	for {
-		select {
-		case msg := <-room.playerA.GetInput():
-			if time.Since(timerA).Milliseconds() < PLAYER_MESSAGE_THROTTLE {
-				continue
-			}
+	stats := make([]PlayerStats, len(players))
+	for i, player := range players {
+		side, _ := engine.MalletSide(rules, i)
+		stats[i] = PlayerStats{Id: player.id, Side: side}
+	}
 
-			message, ok := msg.(PlayerActionMessage)
-			if !ok {
-				continue
-			}
+	var lastHit [engine.MAX_PUCKS]int
+	for i := range lastHit {
+		lastHit[i] = -1
+	}
 
-			room.handlePlayerA(message)
-			timerA = time.Now()
-		case msg := <-room.playerB.GetInput():
-			if time.Since(timerB).Milliseconds() < PLAYER_MESSAGE_THROTTLE {
-				continue
-			}
+	room := &Room{
+		uuid.New(),
+		players,
+		rules,
+		GameSettings{},
+		world,
+		engine.Inputs{},
+		make([]engine.Event, 0, engine.MAX_STEP_EVENTS),
+		PHASE_COUNTDOWN,
+		0,
+		pauses,
+		make([]bool, len(players)),
+		make([]bool, len(players)),
+		stats,
+		lastHit,
+		make(chan presenceChange, MAX_PRESENCE_CHANGES),
+		make(chan MatchEnd),
+		make(chan struct{}),
+	}
+	room.enterPhase(PHASE_COUNTDOWN)
 
-			message, ok := msg.(PlayerActionMessage)
-			if !ok {
-				continue
-			}
+	room.spectatorSettings = room.GameSettings(false)
+	room.spectatorSettings.Spectator = true
 
-			room.handlePlayerB(message)
-			timerB = time.Now()
-		case <-updateTicker.C:
-			room.updateWorldState(time.Since(timerWorld))
-			timerWorld = time.Now()
-		case <-broadcastTicker.C:
-			room.broadcastWorldState()
-		case <-room.exit:
-			updateTicker.Stop()
-			broadcastTicker.Stop()
+	return room
+}
 
-			return
-		}
+func (room *Room) GameSettings(flip bool) GameSettings {
+	return GameSettings{
+		Rink:          DescribeRink(room.world.Rink, room.world.Obstacles, flip),
+		Rules:         room.world.Rules.Name,
+		PuckRadius:    room.world.Rules.PuckRadius,
+		MalletRadius:  room.world.Rules.MalletRadius,
+		MaxGoals:      room.world.Rules.MaxGoals,
+		WinBy:         room.world.Rules.WinBy,
+		Sets:          room.world.Rules.Sets,
+		TimeLimit:     room.world.Rules.TimeLimit,
+		Overtime:      room.world.Rules.Overtime,
+		Pauses:        room.world.Rules.PausesPerPlayer,
+		PauseTime:     room.world.Rules.PauseTime,
+		PhysicsStep:   room.world.Rules.PhysicsStep,
+		PhysicsCycle:  room.world.Rules.PhysicsCycle,
+		NetworkCycle:  room.world.Rules.NetworkCycle,
+		InputCycle:    room.world.Rules.PlayerMessageThrottle,
+		StuckRule:     room.world.Rules.StuckRule,
+		PowerUps:      room.world.Rules.PowerUps,
+		PowerUpRadius: room.world.Rules.PowerUpRadius,
+		TeamSize:      room.world.Rules.TeamSize,
+		TeamZone:      room.world.Rules.TeamZone,
 	}
 }
 
-func (room *Room) handlePlayerA(message PlayerActionMessage) {
-	newPosition := validatePosition(NewPosition(message.x, message.y))
+// PlayerSettings are the game settings from the player's end of the rink, with the zone of the player's own mallet.
+func (room *Room) PlayerSettings(index int) GameSettings {
+	mallet := room.world.Mallets[index]
+	flip := mallet.Side == engine.SIDE_B
 
-	room.world.positionPrevA.x = room.world.positionA.x
-	room.world.positionPrevA.y = room.world.positionA.y
-	room.world.positionA.x = newPosition.x
-	room.world.positionA.y = newPosition.y
-}
+	zone := mallet.Zone
+	if flip {
+		zone = room.world.Rink.FlipZone(zone)
+	}
 
-func (room *Room) handlePlayerB(message PlayerActionMessage) {
-	pos := validatePosition(NewPosition(message.x, message.y))
-	flip := FlipPosition(pos)
+	settings := room.GameSettings(flip)
+	settings.Rink.PlayerZone = describeZone(zone)
 
-	room.world.positionPrevB.x = room.world.positionB.x
-	room.world.positionPrevB.y = room.world.positionB.y
-	room.world.positionB.x = flip.x
-	room.world.positionB.y = flip.y
+	return settings
 }
 
-func (room *Room) updateWorldState(deltaT time.Duration) {
-	newPuckPosition := NewVector(
-		room.world.positionPuck.x+room.world.magnitudePuck.x*float64(deltaT.Milliseconds()),
-		room.world.positionPuck.y+room.world.magnitudePuck.y*float64(deltaT.Milliseconds()),
-	)
+func (room *Room) side(index int) engine.Side {
+	return room.world.Mallets[index].Side
+}
 
-	collision, hitPoint, wallNormal := detectWallHit(room.world.walls, newPuckPosition, room.world.positionPuck)
-	if collision {
-		room.world.magnitudePuck = hitWall(room.world.magnitudePuck, wallNormal)
-		room.world.positionPuck = hitPoint
+func (room *Room) Close(end MatchEnd) {
+	log.Println("Close game: " + room.id.String() + " - " + string(end.Reason) + ": " + end.Message)
 
-		return
+	select {
+	case room.exit <- end:
+	case <-room.done:
 	}
+}
 
-	ok, position, magnitude := detectPlayerHit(
-		room.world.positionA,
-		room.world.positionPrevA,
-		newPuckPosition,
-		room.world.positionPuck,
-		room.world.magnitudePuck,
-		deltaT,
-	)
+func (room *Room) RunGame() {
+	rules := room.world.Rules
+	updateTicker := time.NewTicker(time.Duration(rules.PhysicsCycle) * time.Millisecond)
+	broadcastTicker := time.NewTicker(time.Duration(rules.NetworkCycle) * time.Millisecond)
+
+	inputs := make(chan seatInput)
+	timers := make([]time.Time, len(room.players))
+	for i, player := range room.players {
+		timers[i] = time.Now()
+		go room.forwardInput(i, player.GetInput(), inputs)
+	}
 
-	if ok {
-		room.world.positionPuck = position
-		room.world.magnitudePuck = validateMagnitute(magnitude)
+	timerWorld := time.Now()
+	timerBroadcast := time.Now()
+	var accumulator time.Duration
 
-		return
-	}
+	for {
+		select {
+		case input := <-inputs:
+			room.handleInput(input.index, input.message, &timers[input.index])
+		case <-updateTicker.C:
+			elapsed := time.Since(timerWorld)
+			timerWorld = time.Now()
 
-	ok, position, magnitude = detectPlayerHit(
-		room.world.positionB,
-		room.world.positionPrevB,
-		newPuckPosition,
-		room.world.positionPuck,
-		room.world.magnitudePuck,
-		deltaT,
-	)
+			if room.phase != PHASE_LIVE {
+				room.stepPhase(elapsed)
+				accumulator = 0
 
-	if ok {
-		room.world.positionPuck = position
-		room.world.magnitudePuck = validateMagnitute(magnitude)
+				continue
+			}
 
-		return
-	}
+			accumulator += elapsed
 
-	room.world.positionPuck = newPuckPosition
-	room.world.magnitudePuck = MultiplyVectorNumber(room.world.magnitudePuck, 1-DEFAULT_DRAG)
+			step := time.Duration(rules.PhysicsStep * float64(time.Millisecond))
+			for steps := 0; accumulator >= step; steps++ {
+				if steps == MAX_PHYSICS_STEPS || room.phase != PHASE_LIVE {
+					accumulator = 0
+					break
+				}
 
-	goal, side := detectGoal(*room.world.positionPuck)
-	if goal {
-		room.world.magnitudePuck = NewVector(0, 0)
-		room.world.positionPuck = NewVector(float64(GAME_WIDTH/2), float64(GAME_HEIGHT/2))
+				room.updateWorldState()
+				accumulator -= step
+			}
+		case change := <-room.presence:
+			room.handlePresence(change)
+		case <-broadcastTicker.C:
+			if (room.phase == PHASE_PAUSED || room.phase == PHASE_RECONNECT) && time.Since(timerBroadcast).Milliseconds() < int64(rules.PauseNetworkCycle) {
+				continue
+			}
 
-		if side == -1 {
-			room.world.countA++
-		} else if side == 1 {
-			room.world.countB++
-		}
+			room.broadcastWorldState()
+			timerBroadcast = time.Now()
+		case end := <-room.exit:
+			updateTicker.Stop()
+			broadcastTicker.Stop()
+			close(room.done)
+			room.finish(end)
 
-		if room.world.countA >= MAX_GOALS || room.world.countB >= MAX_GOALS {
-			pool.DeleteRoom(room.id, errors.New("game is finished"))
+			return
 		}
 	}
 }
 
-func (room *Room) broadcastWorldState() {
-	puckPosition := NewPosition(int(math.Round(room.world.positionPuck.x)), int(math.Round(room.world.positionPuck.y)))
-
-	room.playerA.GetWrite() <- NewWorldMessage(
-		*room.world.positionA,
-		*room.world.positionB,
-		*puckPosition,
-		room.world.countA,
-		room.world.countB,
-	)
-
-	room.playerB.GetWrite() <- NewWorldMessage(
-		*FlipPosition(room.world.positionB),
-		*FlipPosition(room.world.positionA),
-		*FlipPosition(puckPosition),
-		room.world.countB,
-		room.world.countA,
-	)
+// seatInput is a message from the player in the seat with the index.
+type seatInput struct {
+	index   int
+	message ClientMessage
 }
 
-func hitWall(magnitude *Vector, wallNormal *Vector) *Vector {
-	mult := 2 * MultiplyVectors(magnitude, wallNormal)
-	multNormal := MultiplyVectorNumber(wallNormal, mult)
-
-	return SubstractVectors(magnitude, multNormal)
+// forwardInput hands the seat's messages to the room's loop until the room is done.
+func (room *Room) forwardInput(index int, input chan ClientMessage, inputs chan<- seatInput) {
+	for {
+		select {
+		case message := <-input:
+			select {
+			case inputs <- seatInput{index, message}:
+			case <-room.done:
+				return
+			}
+		case <-room.done:
+			return
+		}
+	}
 }
 
-func detectPlayerHit(
-	position *Position,
-	prevPosition *Position,
-	puck *Vector,
-	puckPrev *Vector,
-	puckMag *Vector,
-	deltaT time.Duration,
-) (bool, *Vector, *Vector) {
-	lineStart := NewVector(float64(prevPosition.x), float64(prevPosition.y))
-	lineEnd := NewVector(float64(position.x), float64(position.y))
-
-	dX := lineEnd.x - lineStart.x
-	dY := lineEnd.y - lineStart.y
-	playerMagnitude := NewVector(dX/float64(deltaT.Milliseconds()), dY/float64(deltaT.Milliseconds()))
-
-	if DistanceBetweenPoints(lineEnd, puck) < float64(2*ENTITY_RADIUS) {
-		hitVector := NewVectorFromPoints(lineEnd, puck)
-		magnitude := hitWall(puckMag, NormalizeVector(hitVector))
-		resultMagnitude := SumVectors(magnitude, playerMagnitude)
-
-		if (VectorLength(resultMagnitude)) < EPSILON {
-			return false, nil, nil
+// handleInput takes the player's message, moves come no faster than the rules allow.
+func (room *Room) handleInput(index int, msg ClientMessage, lastMove *time.Time) {
+	switch message := msg.(type) {
+	case PlayerActionMessage:
+		if time.Since(*lastMove).Milliseconds() < int64(room.world.Rules.PlayerMessageThrottle) {
+			return
 		}
 
-		positionAfterHit := PointOnLine(lineEnd, puck, float64(2*ENTITY_RADIUS)+COLLISION_DISTANCE)
-
-		return true, positionAfterHit, resultMagnitude
+		room.handlePlayer(index, message)
+		*lastMove = time.Now()
+	case PauseMessage:
+		room.pause(index)
+	case ResumeMessage:
+		room.resume(index)
 	}
+}
 
-	collision, hitPoint := CheckSegmentSegmentIntercection(lineStart, lineEnd, puckPrev, puck)
-	if collision {
-		hitVector := NewVectorFromPoints(hitPoint, puck)
-		magnitude := hitWall(puckMag, NormalizeVector(hitVector))
-		resultMagnitude := SumVectors(magnitude, playerMagnitude)
-
-		positionAfterHit := PointOnLine(hitPoint, puck, float64(2*ENTITY_RADIUS)+COLLISION_DISTANCE)
+// handlePlayer keeps the mallet in its zone, side B's players see the rink flipped.
+func (room *Room) handlePlayer(index int, message PlayerActionMessage) {
+	rink := room.world.Rink
+	mallet := room.world.Mallets[index]
 
-		return true, positionAfterHit, resultMagnitude
+	position := engine.NewPosition(message.x, message.y)
+	if mallet.Side == engine.SIDE_B {
+		position = rink.FlipPosition(position)
 	}
 
-	return false, nil, nil
+	position = mallet.Zone.Clamp(position, room.world.Modifiers(mallet.Side).MalletRadius)
+	room.inputs[index] = &position
 }
 
-func detectWallHit(walls [10][3]*Vector, puck *Vector, puckPrev *Vector) (bool, *Vector, *Vector) {
-	for _, wall := range walls {
-		collision, hitPoint := CheckSegmentSegmentIntercection(wall[0], wall[1], puck, puckPrev)
-		if collision {
-			hitAngle := AngleBetweenLines(puck, puckPrev, wall[0], wall[1])
-			var distance float64
-			angleDegree := RadToDegree(hitAngle)
-			if math.Abs(angleDegree-90) < EPSILON {
-				distance = float64(ENTITY_RADIUS)
-			} else {
-				distance = float64(ENTITY_RADIUS) / math.Sin(hitAngle)
-			}
-
-			hitPoint = PointOnLine(hitPoint, puckPrev, distance+COLLISION_DISTANCE)
-
-			return true, hitPoint, wall[2]
+func (room *Room) updateWorldState() {
+	room.world, room.events = engine.Step(room.world, room.inputs, room.world.Rules.PhysicsStep, room.events[:0])
+	room.inputs = engine.Inputs{}
+
+	for _, event := range room.events {
+		room.recordEvent(event)
+
+		switch event.Type {
+		case engine.EVENT_MATCH_END:
+			room.broadcastEvent(event)
+			pool.DeleteRoom(room.id, NewMatchEnd(END_COMPLETED, nil, "game is finished"))
+		case engine.EVENT_GOAL:
+			room.broadcastEvent(event)
+			room.enterPhase(PHASE_GOAL)
+		case engine.EVENT_STUCK_PUCK, engine.EVENT_SERVE, engine.EVENT_FOUL, engine.EVENT_POWERUP,
+			engine.EVENT_OVERTIME, engine.EVENT_SET_END:
+			room.broadcastEvent(event)
 		}
+	}
+}
 
-		collision, _ = CheckSegmentCircleIntercection(wall[0], wall[1], puck, float64(ENTITY_RADIUS))
-		if collision {
-			_, hitPoint := CheckLineLineIntercection(puck, puckPrev, wall[0], wall[1])
-			hitAngle := AngleBetweenLines(puck, hitPoint, wall[0], wall[1])
-
-			var distance float64
-			angleDegree := RadToDegree(hitAngle)
-			if math.Abs(angleDegree-90) < EPSILON {
-				distance = float64(ENTITY_RADIUS)
-			} else {
-				distance = float64(ENTITY_RADIUS) / math.Sin(hitAngle)
-			}
+func (room *Room) broadcastEvent(event engine.Event) {
+	for i, player := range room.players {
+		player.Send(NewEventMessage(event, room.side(i)))
+	}
+	room.sendSpectators(func() ServerMessage {
+		return NewEventMessage(event, engine.SIDE_NONE)
+	})
+}
 
-			hitPoint = PointOnLine(hitPoint, puck, distance+COLLISION_DISTANCE)
+func (room *Room) broadcastWorldState() {
+	rink := room.world.Rink
+	possessionSide, possessionLeft := room.world.PossessionLeft()
+
+	mallets := room.world.ActiveMallets()
+	malletsA := make([]WorldMallet, 0, len(mallets))
+	malletsB := make([]WorldMallet, 0, len(mallets))
+	for _, mallet := range mallets {
+		position := engine.RoundPosition(mallet.Position)
+		malletsA = append(malletsA, NewWorldMallet(position, RelativeSide(mallet.Side, engine.SIDE_A)))
+		malletsB = append(malletsB, NewWorldMallet(rink.FlipPosition(position), RelativeSide(mallet.Side, engine.SIDE_B)))
+	}
 
-			return true, hitPoint, wall[2]
-		}
+	pucks := room.world.ActivePucks()
+	pucksA := make([]WorldPuck, 0, len(pucks))
+	pucksB := make([]WorldPuck, 0, len(pucks))
+	for _, puck := range pucks {
+		position := engine.RoundPosition(puck.Position)
+		pucksA = append(pucksA, NewWorldPuck(position, puck.Spin))
+		pucksB = append(pucksB, NewWorldPuck(rink.FlipPosition(position), puck.Spin))
 	}
 
-	return false, nil, nil
-}
+	obstaclesA := make([]engine.Position, 0, len(room.world.Obstacles))
+	obstaclesB := make([]engine.Position, 0, len(room.world.Obstacles))
+	for _, obstacle := range room.world.Obstacles {
+		offset := engine.RoundPosition(obstacle.Offset(room.world.Time))
+		obstaclesA = append(obstaclesA, offset)
+		obstaclesB = append(obstaclesB, engine.NewPosition(-offset.X, -offset.Y))
+	}
 
-func detectGoal(puckPosition Vector) (bool, int) {
-	if puckPosition.y-float64(ENTITY_RADIUS) < -float64(GATES_HEIGHT) {
-		return true, -1
+	powerUps := room.world.ActivePowerUps()
+	powerUpsA := make([]WorldPowerUp, 0, len(powerUps))
+	powerUpsB := make([]WorldPowerUp, 0, len(powerUps))
+	for _, powerUp := range powerUps {
+		position := engine.RoundPosition(powerUp.Position)
+		powerUpsA = append(powerUpsA, NewWorldPowerUp(powerUp.Type, position))
+		powerUpsB = append(powerUpsB, NewWorldPowerUp(powerUp.Type, rink.FlipPosition(position)))
 	}
 
-	if puckPosition.y+float64(ENTITY_RADIUS) > float64(GAME_HEIGHT)+float64(GATES_HEIGHT) {
-		return true, 1
+	effects := room.world.ActiveEffects()
+	effectsA := make([]WorldEffect, 0, len(effects))
+	effectsB := make([]WorldEffect, 0, len(effects))
+	for _, effect := range effects {
+		effectsA = append(effectsA, NewWorldEffect(effect, engine.SIDE_A))
+		effectsB = append(effectsB, NewWorldEffect(effect, engine.SIDE_B))
 	}
 
-	return false, 0
-}
+	for i, player := range room.players {
+		if room.side(i) == engine.SIDE_B {
+			player.Send(NewWorldMessage(
+				i,
+				malletsB,
+				pucksB,
+				obstaclesB,
+				room.world.ScoreB,
+				room.world.ScoreA,
+				RelativeSide(possessionSide, engine.SIDE_B),
+				possessionLeft,
+				room.world.ModifiersB,
+				room.world.ModifiersA,
+				powerUpsB,
+				effectsB,
+				NewWorldMatch(room.world.Match, engine.SIDE_B),
+				room.phase,
+				room.phaseLeft,
+			))
+
+			continue
+		}
 
-func validateMagnitute(puckMag *Vector) *Vector {
-	if VectorLength(puckMag) <= MAX_PUCK_MAGNITUDE {
-		return puckMag
+		player.Send(NewWorldMessage(
+			i,
+			malletsA,
+			pucksA,
+			obstaclesA,
+			room.world.ScoreA,
+			room.world.ScoreB,
+			RelativeSide(possessionSide, engine.SIDE_A),
+			possessionLeft,
+			room.world.ModifiersA,
+			room.world.ModifiersB,
+			powerUpsA,
+			effectsA,
+			NewWorldMatch(room.world.Match, engine.SIDE_A),
+			room.phase,
+			room.phaseLeft,
+		))
 	}
 
-	return ResizeVector(puckMag, MAX_PUCK_MAGNITUDE)
+	// Spectators watch from side A's end with the sides named and no mallet of their own.
+	room.sendSpectators(func() ServerMessage {
+		spectatorMallets := make([]WorldMallet, 0, len(mallets))
+		for _, mallet := range mallets {
+			spectatorMallets = append(spectatorMallets, NewWorldMallet(engine.RoundPosition(mallet.Position), RelativeSide(mallet.Side, engine.SIDE_NONE)))
+		}
+
+		spectatorEffects := make([]WorldEffect, 0, len(effects))
+		for _, effect := range effects {
+			spectatorEffects = append(spectatorEffects, NewWorldEffect(effect, engine.SIDE_NONE))
+		}
+
+		return NewWorldMessage(
+			-1,
+			spectatorMallets,
+			pucksA,
+			obstaclesA,
+			room.world.ScoreA,
+			room.world.ScoreB,
+			RelativeSide(possessionSide, engine.SIDE_NONE),
+			possessionLeft,
+			room.world.ModifiersA,
+			room.world.ModifiersB,
+			powerUpsA,
+			spectatorEffects,
+			NewWorldMatch(room.world.Match, engine.SIDE_NONE),
+			room.phase,
+			room.phaseLeft,
+		)
+	})
 }
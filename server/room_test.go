@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"blindwizard.ru/hockey/engine"
+	"github.com/google/uuid"
+)
+
+const TEST_WRITE_BUFFER = 64
+
+// testRoom seats fresh players in a room of the rules preset and puts it in a fresh pool,
+// the room's own loop is not running, so tests drive it step by step.
+func testRoom(t *testing.T, preset string) *Room {
+	loaded, err := LoadRinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := engine.RulesPreset(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool = NewPool()
+
+	players := make([]*Player, 2*rules.TeamSize)
+	for i := range players {
+		players[i] = testPlayer()
+		pool.players[players[i].id] = players[i]
+	}
+
+	room := NewRoom(players, loaded[rules.Rink], rules)
+	for _, player := range players {
+		player.currentRoomId = room.id
+	}
+
+	pool.rooms[room.id] = room
+
+	return room
+}
+
+// testPlayer is connected to nothing, whatever it is sent waits in its write channel.
+func testPlayer() *Player {
+	player := NewPlayer(uuid.New(), uuid.New())
+	player.SetExchange(make(chan ClientMessage), make(chan ServerMessage, TEST_WRITE_BUFFER))
+
+	return player
+}
+
+// sent empties the player's write channel and returns what was in it.
+func sent(player *Player) []string {
+	var messages []string
+
+	for {
+		select {
+		case message := <-player.GetWrite():
+			messages = append(messages, string(message.Stringify()))
+		default:
+			return messages
+		}
+	}
+}
+
+func wasSent(player *Player, message ServerMessage) bool {
+	return slices.Contains(sent(player), string(message.Stringify()))
+}
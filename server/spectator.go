@@ -0,0 +1,122 @@
+package main
+
+import (
+	"log"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// AddSpectator lets the player watch the room, players who are in a match cannot
+// and queued players leave the queue for the stands.
+func (pool *Pool) AddSpectator(player *Player, roomId uuid.UUID) {
+	pool.mutex.Lock()
+	room, exists := pool.rooms[roomId]
+	playing := player.currentRoomId != uuid.Nil
+	pool.mutex.Unlock()
+
+	if !exists || playing {
+		player.Send(NewExitGameMessage("room is not available"))
+
+		return
+	}
+
+	pool.UnQueuePlayer(player)
+	pool.RemoveSpectator(player)
+
+	pool.spectatorsMutex.Lock()
+	pool.spectators[roomId] = append(pool.spectators[roomId], player)
+	player.spectatingRoomId = roomId
+	pool.spectatorsMutex.Unlock()
+
+	player.Send(NewGameMessage(roomId, room.spectatorSettings))
+
+	log.Println("Spectator joined " + roomId.String())
+	pool.updateSpectators(room)
+}
+
+func (pool *Pool) RemoveSpectator(player *Player) {
+	pool.spectatorsMutex.Lock()
+	roomId := player.spectatingRoomId
+	if roomId == uuid.Nil {
+		pool.spectatorsMutex.Unlock()
+
+		return
+	}
+
+	pool.spectators[roomId] = slices.DeleteFunc(pool.spectators[roomId], func(other *Player) bool {
+		return player.id == other.id
+	})
+	if len(pool.spectators[roomId]) == 0 {
+		delete(pool.spectators, roomId)
+	}
+
+	player.spectatingRoomId = uuid.Nil
+	pool.spectatorsMutex.Unlock()
+
+	pool.mutex.Lock()
+	room, exists := pool.rooms[roomId]
+	pool.mutex.Unlock()
+
+	if exists {
+		pool.updateSpectators(room)
+	}
+}
+
+// Spectating tells whether the player watches a room, rooms closing clear it from their own loops.
+func (pool *Pool) Spectating(player *Player) bool {
+	pool.spectatorsMutex.RLock()
+	defer pool.spectatorsMutex.RUnlock()
+
+	return player.spectatingRoomId != uuid.Nil
+}
+
+// Spectators returns a copy of the room's spectators, safe to use from the room's loop.
+func (pool *Pool) Spectators(roomId uuid.UUID) []*Player {
+	pool.spectatorsMutex.RLock()
+	defer pool.spectatorsMutex.RUnlock()
+
+	return slices.Clone(pool.spectators[roomId])
+}
+
+// updateSpectators tells everyone in the room how many are watching.
+func (pool *Pool) updateSpectators(room *Room) {
+	spectators := pool.Spectators(room.id)
+	message := NewSpectatorsMessage(len(spectators))
+
+	for _, player := range room.players {
+		player.Send(message)
+	}
+	for _, spectator := range spectators {
+		spectator.TrySend(message)
+	}
+}
+
+func (pool *Pool) closeSpectators(roomId uuid.UUID, result MatchResult, reason string) {
+	pool.spectatorsMutex.Lock()
+	spectators := pool.spectators[roomId]
+	delete(pool.spectators, roomId)
+	for _, spectator := range spectators {
+		spectator.spectatingRoomId = uuid.Nil
+	}
+	pool.spectatorsMutex.Unlock()
+
+	// The last messages they get are worth waiting for, unlike the worlds they may miss.
+	for _, spectator := range spectators {
+		spectator.Send(NewResultMessage(result, -1))
+		spectator.Send(NewExitGameMessage(reason))
+	}
+}
+
+// sendSpectators never waits for a spectator, a slow one just misses messages.
+func (room *Room) sendSpectators(message func() ServerMessage) {
+	spectators := pool.Spectators(room.id)
+	if len(spectators) == 0 {
+		return
+	}
+
+	built := message()
+	for _, spectator := range spectators {
+		spectator.TrySend(built)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"blindwizard.ru/hockey/engine"
+)
+
+func TestSpectators(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	spectator := testPlayer()
+	pool.players[spectator.id] = spectator
+
+	pool.AddSpectator(spectator, room.id)
+	if !pool.Spectating(spectator) || len(pool.Spectators(room.id)) != 1 {
+		t.Fatal("spectator not watching the room")
+	}
+
+	if !wasSent(spectator, NewGameMessage(room.id, room.spectatorSettings)) {
+		t.Fatal("spectator not sent the game")
+	}
+
+	if !wasSent(room.players[0], NewSpectatorsMessage(1)) {
+		t.Fatal("players not told about the spectator")
+	}
+
+	pool.RemoveSpectator(spectator)
+	if pool.Spectating(spectator) || len(pool.Spectators(room.id)) != 0 {
+		t.Fatal("spectator still watching the room")
+	}
+
+	if !wasSent(room.players[1], NewSpectatorsMessage(0)) {
+		t.Fatal("players not told the spectator left")
+	}
+}
+
+func TestSpectatorJoinRules(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+
+	pool.AddSpectator(room.players[0], room.id)
+	if pool.Spectating(room.players[0]) {
+		t.Fatal("player in the match is spectating it")
+	}
+
+	queued := testPlayer()
+	pool.players[queued.id] = queued
+	pool.QueuePlayer(queued, engine.RULES_RANKED, "")
+
+	pool.AddSpectator(queued, room.id)
+	if !pool.Spectating(queued) || pool.queueLength() != 0 {
+		t.Fatal("queued player not moved from the queue to the stands")
+	}
+}
+
+func TestSpectatorsAtTheEnd(t *testing.T) {
+	room := testRoom(t, engine.RULES_RANKED)
+	spectator := testPlayer()
+	pool.AddSpectator(spectator, room.id)
+	sent(spectator)
+
+	result := room.result(NewMatchEnd(END_ADMIN, nil, "server is shutting down"))
+	pool.closeSpectators(room.id, result, "server is shutting down")
+
+	messages := sent(spectator)
+	if len(messages) != 2 || messages[0] != string(NewResultMessage(result, -1).Stringify()) ||
+		messages[1] != string(NewExitGameMessage("server is shutting down").Stringify()) {
+		t.Fatalf("spectator sent %v at the end", messages)
+	}
+
+	if pool.Spectating(spectator) {
+		t.Fatal("spectator still watching a closed room")
+	}
+}